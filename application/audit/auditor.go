@@ -0,0 +1,355 @@
+package audit
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	infrastructureTime "github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/diez37/go-packages/log"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const (
+	// appendAttempts count of attempts to append the entry to the chain changed concurrently
+	appendAttempts = 3
+
+	// queueSize count of recorded entries waiting for the writer, Record blocks while the queue is full
+	queueSize = 1024
+
+	// verifyLimit count of entries read at once on verification
+	verifyLimit = 500
+)
+
+type Auditor interface {
+	// Record queuing of the event for appending to the audit log by Write, the request is taken from the context,
+	// failures are logged and do not break the audited operation
+	Record(ctx context.Context, action domain.AuditAction, login uuid.UUID, password uuid.UUID)
+
+	// Write appending of recorded events to the chain until the context is done, events queued before
+	// are written on shutdown, it is the only writer of the chain of the instance and is called once
+	Write(ctx context.Context)
+
+	// Page return entries of the login created in [from, to] and total count of them, nil bounds are not applied
+	Page(ctx context.Context, login uuid.UUID, from *time.Time, to *time.Time, page uint, limit uint) ([]*domain.AuditEntry, int64, error)
+
+	// Checkpoint signing of the last entry of the chain, nothing is done if it is already signed
+	Checkpoint(ctx context.Context) error
+
+	// Verify walking of the whole chain with checking of hashes and signatures of checkpoints
+	Verify(ctx context.Context) (*domain.AuditVerification, error)
+}
+
+type auditor struct {
+	// entries are appended by the single writer, the unique index of previous hashes rejects forks of other instances
+	entries chan *repository.AuditEntry
+	stopped chan struct{}
+	key     ed25519.PrivateKey
+
+	config     *config.Audit
+	repository repository.AuditRepository
+	logger     log.Logger
+	tracer     trace.Tracer
+}
+
+// NewAuditor auditor of the server signing checkpoints, the key is generated if config.Audit.KeyFile does not exist
+func NewAuditor(config *config.Audit, repository repository.AuditRepository, logger log.Logger, tracer trace.Tracer) (Auditor, error) {
+	key, err := loadKey(config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAuditor(key, config, repository, logger, tracer), nil
+}
+
+// NewVerifier auditor for verification of the chain with the existing key, a generated key would not match
+// signatures of checkpoints, so MissingKeyError is returned if config.Audit.KeyFile does not exist
+func NewVerifier(config *config.Audit, repository repository.AuditRepository, logger log.Logger, tracer trace.Tracer) (Auditor, error) {
+	key, err := readKey(config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAuditor(key, config, repository, logger, tracer), nil
+}
+
+func newAuditor(key ed25519.PrivateKey, config *config.Audit, auditRepository repository.AuditRepository, logger log.Logger, tracer trace.Tracer) Auditor {
+	return &auditor{
+		entries:    make(chan *repository.AuditEntry, queueSize),
+		stopped:    make(chan struct{}),
+		key:        key,
+		config:     config,
+		repository: auditRepository,
+		logger:     logger,
+		tracer:     tracer,
+	}
+}
+
+func (service *auditor) Record(ctx context.Context, action domain.AuditAction, login uuid.UUID, password uuid.UUID) {
+	ctx, span := service.tracer.Start(ctx, "Record")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("service", "audit"),
+		attribute.String("action", string(action)),
+	)
+
+	request := fromContext(ctx)
+
+	entry := &repository.AuditEntry{
+		Login:     login,
+		Password:  password,
+		Action:    string(action),
+		RequestId: request.id,
+		Address:   request.address,
+	}
+
+	select {
+	case service.entries <- entry:
+	case <-service.stopped:
+		service.logger.Errorf("audit: %s of password %s of login %s is not recorded: writer is stopped", action, password, login)
+	}
+}
+
+func (service *auditor) Write(ctx context.Context) {
+	for {
+		select {
+		case entry := <-service.entries:
+			service.write(ctx, entry)
+		case <-ctx.Done():
+			close(service.stopped)
+
+			// the context is done, so entries queued before the shutdown are written without it
+			for {
+				select {
+				case entry := <-service.entries:
+					service.write(context.Background(), entry)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (service *auditor) write(ctx context.Context, entry *repository.AuditEntry) {
+	ctx, span := service.tracer.Start(ctx, "Write")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("service", "audit"),
+		attribute.String("action", entry.Action),
+	)
+
+	var err error
+
+	// another instance may append to the chain at the same time, the entry is chained to the new last one
+	for attempt := 0; attempt < appendAttempts; attempt++ {
+		if err = service.append(ctx, entry); err == nil {
+			return
+		}
+	}
+
+	service.logger.Errorf("audit: %s of password %s of login %s is not recorded: %s", entry.Action, entry.Password, entry.Login, err)
+}
+
+func (service *auditor) append(ctx context.Context, entry *repository.AuditEntry) error {
+	previous := ""
+
+	last, err := service.repository.LastAudit(ctx)
+	if err != nil && err != db.RecordNotFoundError {
+		return err
+	}
+
+	if last != nil {
+		previous = last.Hash
+	}
+
+	now := infrastructureTime.NowUTC()
+
+	entry.Uuid = uuid.New()
+	entry.CreatedAt = &now
+	entry.PreviousHash = previous
+	entry.Hash = hashEntry(previous, entry)
+
+	_, err = service.repository.InsertAudit(ctx, entry)
+
+	return err
+}
+
+func (service *auditor) Page(ctx context.Context, login uuid.UUID, from *time.Time, to *time.Time, page uint, limit uint) ([]*domain.AuditEntry, int64, error) {
+	ctx, span := service.tracer.Start(ctx, "Page")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "audit"))
+
+	count, err := service.repository.CountAudit(ctx, login, from, to)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	models, err := service.repository.PageAudit(ctx, login, from, to, page, limit)
+	if err != nil && err != db.RecordNotFoundError {
+		return nil, 0, err
+	}
+
+	entries := make([]*domain.AuditEntry, len(models))
+	for index, model := range models {
+		entries[index] = &domain.AuditEntry{
+			Uuid:      model.Uuid,
+			Login:     model.Login,
+			Password:  model.Password,
+			Action:    domain.AuditAction(model.Action),
+			RequestId: model.RequestId,
+			Address:   model.Address,
+			CreatedAt: model.CreatedAt,
+		}
+	}
+
+	return entries, count, nil
+}
+
+func (service *auditor) Checkpoint(ctx context.Context) error {
+	ctx, span := service.tracer.Start(ctx, "Checkpoint")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "audit"))
+
+	last, err := service.repository.LastAudit(ctx)
+	if err == db.RecordNotFoundError {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// entries written before chaining cannot be signed
+	if last.Hash == "" {
+		return nil
+	}
+
+	checkpoint, err := service.repository.LastCheckpoint(ctx)
+	if err != nil && err != db.RecordNotFoundError {
+		return err
+	}
+
+	if checkpoint != nil && checkpoint.AuditId == last.Id {
+		return nil
+	}
+
+	signature := ed25519.Sign(service.key, checkpointMessage(last.Id, last.Hash))
+
+	_, err = service.repository.InsertCheckpoint(ctx, &repository.AuditCheckpoint{
+		AuditId:   last.Id,
+		Hash:      last.Hash,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	})
+
+	return err
+}
+
+func (service *auditor) Verify(ctx context.Context) (*domain.AuditVerification, error) {
+	ctx, span := service.tracer.Start(ctx, "Verify")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "audit"))
+
+	checkpoints, err := service.repository.Checkpoints(ctx)
+	if err != nil && err != db.RecordNotFoundError {
+		return nil, err
+	}
+
+	public := service.key.Public().(ed25519.PublicKey)
+	verification := &domain.AuditVerification{}
+
+	signed := map[int64]*repository.AuditCheckpoint{}
+	for _, checkpoint := range checkpoints {
+		signature, err := base64.StdEncoding.DecodeString(checkpoint.Signature)
+		if err != nil || !ed25519.Verify(public, checkpointMessage(checkpoint.AuditId, checkpoint.Hash), signature) {
+			verification.Break = &domain.AuditBreak{Id: checkpoint.AuditId, Reason: "invalid signature of checkpoint"}
+			return verification, nil
+		}
+
+		signed[checkpoint.AuditId] = checkpoint
+	}
+
+	previous := ""
+	chained := false
+	after := int64(0)
+
+	for {
+		entries, err := service.repository.ChainAudit(ctx, after, verifyLimit)
+		if err == db.RecordNotFoundError {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			after = entry.Id
+
+			if reason := service.verifyEntry(entry, previous, chained, signed[entry.Id]); reason != "" {
+				verification.Break = &domain.AuditBreak{Id: entry.Id, Uuid: entry.Uuid, Reason: reason}
+				return verification, nil
+			}
+
+			if entry.Hash == "" {
+				verification.Unchained++
+				continue
+			}
+
+			if signed[entry.Id] != nil {
+				verification.Checkpoints++
+				delete(signed, entry.Id)
+			}
+
+			chained = true
+			previous = entry.Hash
+			verification.Entries++
+		}
+	}
+
+	// the signed entry is not found, so the tail of the chain is deleted
+	for _, checkpoint := range checkpoints {
+		if signed[checkpoint.AuditId] != nil {
+			verification.Break = &domain.AuditBreak{Id: checkpoint.AuditId, Reason: "entry of checkpoint is missing"}
+			return verification, nil
+		}
+	}
+
+	return verification, nil
+}
+
+// verifyEntry return the reason of the break of the chain at the entry, empty if the entry is valid
+func (service *auditor) verifyEntry(entry *repository.AuditEntry, previous string, chained bool, checkpoint *repository.AuditCheckpoint) string {
+	if entry.Hash == "" {
+		if chained {
+			return "entry is not chained"
+		}
+
+		return ""
+	}
+
+	if entry.PreviousHash != previous {
+		return "previous hash does not match, an entry before is deleted or inserted"
+	}
+
+	if hashEntry(previous, entry) != entry.Hash {
+		return "hash does not match, the entry is changed"
+	}
+
+	if checkpoint != nil && checkpoint.Hash != entry.Hash {
+		return "hash does not match the signed checkpoint"
+	}
+
+	return ""
+}
@@ -0,0 +1,309 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testConflictError = errors.New("previous hash is not unique")
+
+// testRepository in-memory chain of entries and checkpoints, the next conflicts inserts fail
+type testRepository struct {
+	repository.AuditRepository
+
+	entries     []*repository.AuditEntry
+	checkpoints []*repository.AuditCheckpoint
+	conflicts   int
+}
+
+func (fake *testRepository) InsertAudit(_ context.Context, entry *repository.AuditEntry) (*repository.AuditEntry, error) {
+	if fake.conflicts > 0 {
+		fake.conflicts--
+		return nil, testConflictError
+	}
+
+	entry.Id = int64(len(fake.entries) + 1)
+	fake.entries = append(fake.entries, entry)
+
+	return entry, nil
+}
+
+func (fake *testRepository) LastAudit(context.Context) (*repository.AuditEntry, error) {
+	if len(fake.entries) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return fake.entries[len(fake.entries)-1], nil
+}
+
+func (fake *testRepository) ChainAudit(_ context.Context, after int64, limit uint) ([]*repository.AuditEntry, error) {
+	var entries []*repository.AuditEntry
+	for _, entry := range fake.entries {
+		if entry.Id > after && uint(len(entries)) < limit {
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return entries, nil
+}
+
+func (fake *testRepository) InsertCheckpoint(_ context.Context, checkpoint *repository.AuditCheckpoint) (*repository.AuditCheckpoint, error) {
+	fake.checkpoints = append(fake.checkpoints, checkpoint)
+	return checkpoint, nil
+}
+
+func (fake *testRepository) LastCheckpoint(context.Context) (*repository.AuditCheckpoint, error) {
+	if len(fake.checkpoints) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return fake.checkpoints[len(fake.checkpoints)-1], nil
+}
+
+func (fake *testRepository) Checkpoints(context.Context) ([]*repository.AuditCheckpoint, error) {
+	if len(fake.checkpoints) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return fake.checkpoints, nil
+}
+
+func TestKey(t *testing.T) {
+	directory := t.TempDir()
+
+	if err := ioutil.WriteFile(filepath.Join(directory, "short.key"), []byte("c2hvcnQ=\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		load    func(file string) error
+		err     error
+		created bool
+	}{
+		{
+			name:    "server generates the missing key",
+			file:    "server.key",
+			load:    func(file string) error { _, err := loadKey(file); return err },
+			created: true,
+		},
+		{
+			name: "verification requires the key",
+			file: "verify.key",
+			load: func(file string) error { _, err := readKey(file); return err },
+			err:  MissingKeyError,
+		},
+		{
+			name: "invalid key is not replaced",
+			file: "short.key",
+			load: func(file string) error { _, err := loadKey(file); return err },
+			err:  InvalidKeyError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(directory, test.file)
+			_, statErr := os.Stat(file)
+
+			if err := test.load(file); !errors.Is(err, test.err) {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			_, err := os.Stat(file)
+			if created := os.IsNotExist(statErr) && err == nil; created != test.created {
+				t.Errorf("created %t, want %t", created, test.created)
+			}
+		})
+	}
+}
+
+func TestAuditorRecord(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		conflicts int
+		stopped   bool
+		requestId string
+		address   string
+		recorded  bool
+	}{
+		{name: "entry outside of requests", ctx: context.Background(), recorded: true},
+		{
+			name:      "entry of the request",
+			ctx:       WithRequest(context.Background(), "request", "127.0.0.1"),
+			requestId: "request",
+			address:   "127.0.0.1",
+			recorded:  true,
+		},
+		{name: "entry appended after a conflict", ctx: context.Background(), conflicts: appendAttempts - 1, recorded: true},
+		{name: "entry of a failed append is dropped", ctx: context.Background(), conflicts: appendAttempts},
+		{name: "entry after the shutdown of the writer is dropped", ctx: context.Background(), stopped: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &testRepository{}
+
+			service, err := NewAuditor(
+				&config.Audit{KeyFile: filepath.Join(t.TempDir(), "audit.key")},
+				repository,
+				logrus.New(),
+				trace.NewNoopTracerProvider().Tracer(""),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			writer := service.(*auditor)
+
+			service.Record(context.Background(), domain.AddAction, uuid.New(), uuid.New())
+			writer.write(context.Background(), <-writer.entries)
+
+			repository.conflicts = test.conflicts
+			login, password := uuid.New(), uuid.New()
+
+			// the writer of the done context writes the queued entries and stops
+			ctx, cancelFunc := context.WithCancel(context.Background())
+			cancelFunc()
+
+			if test.stopped {
+				service.Write(ctx)
+			}
+
+			service.Record(test.ctx, domain.CheckSuccessAction, login, password)
+
+			if recorded := len(repository.entries) == 2; recorded {
+				t.Fatalf("entry is appended on the request path")
+			}
+
+			if !test.stopped {
+				service.Write(ctx)
+			}
+
+			if recorded := len(repository.entries) == 2; recorded != test.recorded {
+				t.Fatalf("recorded %t, want %t", recorded, test.recorded)
+			}
+
+			if !test.recorded {
+				return
+			}
+
+			entry := repository.entries[1]
+
+			if entry.Login != login || entry.Password != password || entry.Action != string(domain.CheckSuccessAction) {
+				t.Errorf("entry %+v is not of the recorded event", entry)
+			}
+
+			if entry.RequestId != test.requestId || entry.Address != test.address {
+				t.Errorf("request %s from %s, want %s from %s", entry.RequestId, entry.Address, test.requestId, test.address)
+			}
+
+			if entry.PreviousHash != repository.entries[0].Hash {
+				t.Errorf("entry is not chained to the previous one")
+			}
+		})
+	}
+}
+
+func TestAuditorVerify(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(repository *testRepository)
+		reason string
+	}{
+		{name: "valid chain", change: func(*testRepository) {}},
+		{
+			name:   "changed entry",
+			change: func(repository *testRepository) { repository.entries[1].Action = string(domain.AddAction) },
+			reason: "hash does not match, the entry is changed",
+		},
+		{
+			name: "deleted entry",
+			change: func(repository *testRepository) {
+				repository.entries = append(repository.entries[:1], repository.entries[2:]...)
+			},
+			reason: "previous hash does not match, an entry before is deleted or inserted",
+		},
+		{
+			name: "forged checkpoint",
+			change: func(repository *testRepository) {
+				repository.checkpoints[0].Signature = repository.checkpoints[1].Signature
+			},
+			reason: "invalid signature of checkpoint",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auditConfig := &config.Audit{KeyFile: filepath.Join(t.TempDir(), "audit.key")}
+			repository := &testRepository{}
+			ctx := context.Background()
+
+			server, err := NewAuditor(auditConfig, repository, logrus.New(), trace.NewNoopTracerProvider().Tracer(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			writer := server.(*auditor)
+
+			for _, action := range []domain.AuditAction{domain.AddAction, domain.CheckSuccessAction, domain.DisabledAction} {
+				server.Record(ctx, action, uuid.New(), uuid.New())
+				writer.write(ctx, <-writer.entries)
+
+				if err := server.Checkpoint(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			test.change(repository)
+
+			verifier, err := NewVerifier(auditConfig, repository, logrus.New(), trace.NewNoopTracerProvider().Tracer(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verification, err := verifier.Verify(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reason := ""
+			if verification.Break != nil {
+				reason = verification.Break.Reason
+			}
+
+			if reason != test.reason {
+				t.Errorf("break %q, want %q", reason, test.reason)
+			}
+		})
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	auditConfig := &config.Audit{KeyFile: filepath.Join(t.TempDir(), "audit.key")}
+
+	if _, err := NewVerifier(auditConfig, &testRepository{}, logrus.New(), trace.NewNoopTracerProvider().Tracer("")); !errors.Is(err, MissingKeyError) {
+		t.Fatalf("error %v, want %v", err, MissingKeyError)
+	}
+
+	if _, err := os.Stat(auditConfig.KeyFile); !os.IsNotExist(err) {
+		t.Errorf("key file is created by the verification: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	InvalidKeyError = errors.New("invalid audit key")
+	MissingKeyError = errors.New("audit key is missing")
+)
+
+// hashEntry hash of the entry chained to the previous hash, time is taken with second precision
+// because not every db keeps fractions of seconds
+func hashEntry(previous string, entry *repository.AuditEntry) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		previous,
+		entry.Uuid.String(),
+		entry.Login.String(),
+		entry.Password.String(),
+		entry.Action,
+		entry.RequestId,
+		entry.Address,
+		entry.CreatedAt.UTC().Truncate(time.Second).Format(time.RFC3339),
+	}, "\n")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// checkpointMessage signed content of the checkpoint
+func checkpointMessage(auditId int64, hash string) []byte {
+	return []byte(fmt.Sprintf("%d:%s", auditId, hash))
+}
+
+// loadKey reading of the Ed25519 key from the file with the base64 encoded seed, the key is generated
+// and written to the file if the file does not exist
+func loadKey(file string) (ed25519.PrivateKey, error) {
+	key, err := readKey(file)
+	if !errors.Is(err, MissingKeyError) {
+		return key, err
+	}
+
+	_, key, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(key.Seed())
+	if err := ioutil.WriteFile(file, []byte(encoded+"\n"), 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// readKey reading of the Ed25519 key from the file with the base64 encoded seed,
+// MissingKeyError is returned if the file does not exist
+func readKey(file string) (ed25519.PrivateKey, error) {
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", MissingKeyError, file)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seed) != ed25519.SeedSize {
+		return nil, InvalidKeyError
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,28 @@
+package audit
+
+import "context"
+
+type requestKey struct{}
+
+type request struct {
+	id      string
+	address string
+}
+
+// WithRequest return context carrying id and client address of the request, they are written to audit entries
+func WithRequest(ctx context.Context, id string, address string) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{id: id, address: address})
+}
+
+func fromContext(ctx context.Context) *request {
+	if value, ok := ctx.Value(requestKey{}).(*request); ok {
+		return value
+	}
+
+	return &request{}
+}
+
+// RequestId return id of the request passed by WithRequest, empty outside of requests
+func RequestId(ctx context.Context) string {
+	return fromContext(ctx).id
+}
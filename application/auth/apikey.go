@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/domain"
+	"net/http"
+	"strings"
+)
+
+const ApiKeyHeaderName = "X-Api-Key"
+
+var InvalidKeyHashError = errors.New("invalid hash of api key")
+
+// apiKey authentication by static keys, only SHA-256 hashes of keys are stored
+type apiKey struct {
+	hashes map[string][]byte
+}
+
+func newApiKey(keys map[string]string) (*apiKey, error) {
+	method := &apiKey{hashes: map[string][]byte{}}
+
+	for identity, encoded := range keys {
+		hash, err := hex.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("%w: %s", InvalidKeyHashError, identity)
+		}
+
+		method.hashes[identity] = hash
+	}
+
+	return method, nil
+}
+
+func (method *apiKey) authenticate(_ context.Context, request *http.Request) (string, []domain.Role, bool, error) {
+	key := request.Header.Get(ApiKeyHeaderName)
+	if key == "" {
+		return "", nil, false, nil
+	}
+
+	hash := sha256.Sum256([]byte(key))
+
+	// every hash is compared to not leak the position of the matching key by timing
+	identity := ""
+	for name, expected := range method.hashes {
+		if subtle.ConstantTimeCompare(hash[:], expected) == 1 {
+			identity = name
+		}
+	}
+
+	if identity == "" {
+		return "", nil, false, UnauthenticatedError
+	}
+
+	return identity, nil, true, nil
+}
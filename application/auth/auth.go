@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+)
+
+var (
+	UnauthenticatedError = errors.New("caller is not authenticated")
+	UnknownMethodError   = errors.New("unknown method of authentication")
+	UnknownRoleError     = errors.New("unknown role of caller")
+	MissingMethodsError  = errors.New("methods of authentication are not set and authentication is not disabled")
+	DisabledMethodsError = errors.New("methods of authentication are set while authentication is disabled")
+)
+
+// identitySeparator separator of the name of the method and the identity of the caller
+const identitySeparator = ":"
+
+// anonymous caller of the api with disabled authentication
+var anonymous = &domain.Caller{Roles: []domain.Role{domain.AdminRole}}
+
+type Authenticator interface {
+	// Authenticate return the caller of the request by the first of configured methods whose credentials
+	// are passed, UnauthenticatedError if credentials are missing or invalid
+	Authenticate(ctx context.Context, request *http.Request) (*domain.Caller, error)
+}
+
+// method verifying of credentials of one kind, ok is false if the request carries no credentials of the kind,
+// UnauthenticatedError if they are invalid
+type method interface {
+	authenticate(ctx context.Context, request *http.Request) (identity string, roles []domain.Role, ok bool, err error)
+}
+
+// namedMethod method with the name of config.Auth Methods, identities of the method are namespaced by the name,
+// so a key named like the subject of a token is another caller
+type namedMethod struct {
+	method
+
+	name string
+}
+
+type authenticator struct {
+	disabled bool
+	methods  []*namedMethod
+	roles    map[string]domain.Role
+
+	tracer trace.Tracer
+}
+
+func NewAuthenticator(authConfig *config.Auth, tracer trace.Tracer) (Authenticator, error) {
+	if authConfig.Disabled && len(authConfig.Methods) > 0 {
+		return nil, DisabledMethodsError
+	}
+
+	if !authConfig.Disabled && len(authConfig.Methods) == 0 {
+		return nil, MissingMethodsError
+	}
+
+	service := &authenticator{disabled: authConfig.Disabled, roles: map[string]domain.Role{}, tracer: tracer}
+
+	for _, name := range authConfig.Methods {
+		var method method
+
+		switch name {
+		case config.ApiKeyAuthMethod:
+			apiKey, err := newApiKey(authConfig.Keys)
+			if err != nil {
+				return nil, err
+			}
+
+			method = apiKey
+		case config.HmacAuthMethod:
+			method = newHmac(authConfig.HmacSecrets, authConfig.HmacSkew)
+		case config.JwtAuthMethod:
+			jwt, err := newJwt(authConfig.JwtJwks, authConfig.JwtIssuer, authConfig.JwtAudience)
+			if err != nil {
+				return nil, err
+			}
+
+			method = jwt
+		case config.MtlsAuthMethod:
+			method = newMtls(authConfig.MtlsIdentities)
+		default:
+			return nil, fmt.Errorf("%w: %s", UnknownMethodError, name)
+		}
+
+		service.methods = append(service.methods, &namedMethod{method: method, name: name})
+	}
+
+	for identity, role := range authConfig.Roles {
+		if role := domain.Role(role); role != domain.CheckRole && role != domain.AdminRole {
+			return nil, fmt.Errorf("%w: %s", UnknownRoleError, role)
+		}
+
+		// roles of identities without the namespace of a configured method would be silently ignored
+		if !service.namespaced(identity) {
+			return nil, fmt.Errorf("%w: %s", UnknownMethodError, identity)
+		}
+
+		service.roles[identity] = domain.Role(role)
+	}
+
+	return service, nil
+}
+
+// namespaced whether the identity is prefixed with the name of a configured method
+func (service *authenticator) namespaced(identity string) bool {
+	for _, method := range service.methods {
+		if strings.HasPrefix(identity, method.name+identitySeparator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (service *authenticator) Authenticate(ctx context.Context, request *http.Request) (*domain.Caller, error) {
+	ctx, span := service.tracer.Start(ctx, "Authenticate")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "auth"))
+
+	if service.disabled {
+		return anonymous, nil
+	}
+
+	for _, method := range service.methods {
+		identity, roles, ok, err := method.authenticate(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		identity = method.name + identitySeparator + identity
+
+		if role, ok := service.roles[identity]; ok {
+			roles = append(roles, role)
+		}
+
+		return &domain.Caller{Identity: identity, Roles: roles}, nil
+	}
+
+	return nil, UnauthenticatedError
+}
@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/go-http-utils/headers"
+	"go.opentelemetry.io/otel/trace"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	goTime "time"
+)
+
+const (
+	testKey    = "frontend key"
+	testSecret = "billing secret"
+	testKid    = "test"
+)
+
+// testSigner ES256 key signing bearer tokens of tests, the public part is written to the JWKS file
+type testSigner struct {
+	key  *ecdsa.PrivateKey
+	jwks string
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := json.Marshal(map[string][]map[string]string{"keys": {{
+		"kty": "EC",
+		"kid": testKid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwks := filepath.Join(t.TempDir(), "jwks.json")
+	if err := ioutil.WriteFile(jwks, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testSigner{key: key, jwks: jwks}
+}
+
+// token compact serialized token with the claims
+func (signer *testSigner) token(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": es256Algorithm, "kid": testKid})
+	payload, _ := json.Marshal(claims)
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	r, s, err := ecdsa.Sign(rand.Reader, signer.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// signedRequest request signed like callers of the hmac method do
+func signedRequest(secret string, signedAt goTime.Time, nonce string) *http.Request {
+	body := `{"login":"0b9f5d2e-6b1f-4a51-9d3c-2f2c7f7e1a11"}`
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/password/check", strings.NewReader(body))
+
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+
+	request.Header.Set(IdHeaderName, "billing")
+	request.Header.Set(TimestampHeaderName, timestamp)
+	request.Header.Set(NonceHeaderName, nonce)
+	request.Header.Set(SignatureHeaderName, Sign(secret, request.Method, request.URL.RequestURI(), timestamp, nonce, []byte(body)))
+
+	return request
+}
+
+func testAuthConfig(jwks string) *config.Auth {
+	hash := sha256.Sum256([]byte(testKey))
+
+	return &config.Auth{
+		Methods:     []string{config.ApiKeyAuthMethod, config.HmacAuthMethod, config.JwtAuthMethod, config.MtlsAuthMethod},
+		Keys:        map[string]string{"frontend": hex.EncodeToString(hash[:])},
+		HmacSecrets: map[string]string{"billing": testSecret},
+		HmacSkew:    goTime.Minute,
+		JwtJwks:     jwks,
+		Roles: map[string]string{
+			"apikey:frontend": string(domain.CheckRole),
+			"hmac:billing":    string(domain.CheckRole),
+			"jwt:frontend":    string(domain.AdminRole),
+		},
+	}
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	signer := newTestSigner(t)
+
+	tests := []struct {
+		name   string
+		change func(authConfig *config.Auth)
+		err    error
+	}{
+		{name: "configured methods", change: func(*config.Auth) {}},
+		{
+			name:   "explicitly disabled",
+			change: func(authConfig *config.Auth) { *authConfig = config.Auth{Disabled: true} },
+		},
+		{
+			name:   "methods are not set",
+			change: func(authConfig *config.Auth) { authConfig.Methods = nil },
+			err:    MissingMethodsError,
+		},
+		{
+			name:   "disabled with methods",
+			change: func(authConfig *config.Auth) { authConfig.Disabled = true },
+			err:    DisabledMethodsError,
+		},
+		{
+			name:   "unknown method",
+			change: func(authConfig *config.Auth) { authConfig.Methods = append(authConfig.Methods, "basic") },
+			err:    UnknownMethodError,
+		},
+		{
+			name:   "role of identity without namespace",
+			change: func(authConfig *config.Auth) { authConfig.Roles["frontend"] = string(domain.CheckRole) },
+			err:    UnknownMethodError,
+		},
+		{
+			name: "role of identity of not configured method",
+			change: func(authConfig *config.Auth) {
+				authConfig.Methods = []string{config.ApiKeyAuthMethod}
+			},
+			err: UnknownMethodError,
+		},
+		{
+			name:   "unknown role",
+			change: func(authConfig *config.Auth) { authConfig.Roles["apikey:frontend"] = "root" },
+			err:    UnknownRoleError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authConfig := testAuthConfig(signer.jwks)
+			test.change(authConfig)
+
+			_, err := NewAuthenticator(authConfig, trace.NewNoopTracerProvider().Tracer(""))
+			if !errors.Is(err, test.err) {
+				t.Errorf("error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	signer := newTestSigner(t)
+
+	authenticator, err := NewAuthenticator(testAuthConfig(signer.jwks), trace.NewNoopTracerProvider().Tracer(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disabled, err := NewAuthenticator(&config.Auth{Disabled: true}, trace.NewNoopTracerProvider().Tracer(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.NowUTC()
+	expiresAt := now.Add(goTime.Minute).Unix()
+
+	// the nonce of the signed request is replayed by the next case
+	signed := signedRequest(testSecret, now, "replayed nonce")
+
+	tests := []struct {
+		name          string
+		authenticator Authenticator
+		request       func() *http.Request
+		identity      string
+		roles         []domain.Role
+		err           error
+	}{
+		{
+			name:          "disabled authentication",
+			authenticator: disabled,
+			request:       func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			roles:         []domain.Role{domain.AdminRole},
+		},
+		{
+			name:    "without credentials",
+			request: func() *http.Request { return httptest.NewRequest(http.MethodGet, "/", nil) },
+			err:     UnauthenticatedError,
+		},
+		{
+			name: "api key",
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.Header.Set(ApiKeyHeaderName, testKey)
+
+				return request
+			},
+			identity: "apikey:frontend",
+			roles:    []domain.Role{domain.CheckRole},
+		},
+		{
+			name: "wrong api key",
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.Header.Set(ApiKeyHeaderName, "wrong key")
+
+				return request
+			},
+			err: UnauthenticatedError,
+		},
+		{
+			name:     "signed request",
+			request:  func() *http.Request { return signed },
+			identity: "hmac:billing",
+			roles:    []domain.Role{domain.CheckRole},
+		},
+		{
+			name: "replayed signed request",
+			request: func() *http.Request {
+				return signedRequest(testSecret, now, "replayed nonce")
+			},
+			err: UnauthenticatedError,
+		},
+		{
+			name:     "signed request with another nonce",
+			request:  func() *http.Request { return signedRequest(testSecret, now, "another nonce") },
+			identity: "hmac:billing",
+			roles:    []domain.Role{domain.CheckRole},
+		},
+		{
+			name:    "signed request without nonce",
+			request: func() *http.Request { return signedRequest(testSecret, now, "") },
+			err:     UnauthenticatedError,
+		},
+		{
+			name:    "signed request out of the skew",
+			request: func() *http.Request { return signedRequest(testSecret, now.Add(-goTime.Hour), "stale nonce") },
+			err:     UnauthenticatedError,
+		},
+		{
+			name:    "signed with another secret",
+			request: func() *http.Request { return signedRequest("wrong secret", now, "forged nonce") },
+			err:     UnauthenticatedError,
+		},
+		{
+			name: "bearer token with the subject of the api key",
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.Header.Set(headers.Authorization, bearerPrefix+signer.token(t, map[string]interface{}{
+					"sub":   "frontend",
+					"exp":   expiresAt,
+					"roles": []string{string(domain.CheckRole)},
+				}))
+
+				return request
+			},
+			identity: "jwt:frontend",
+			roles:    []domain.Role{domain.CheckRole, domain.AdminRole},
+		},
+		{
+			name: "expired bearer token",
+			request: func() *http.Request {
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				request.Header.Set(headers.Authorization, bearerPrefix+signer.token(t, map[string]interface{}{
+					"sub": "frontend",
+					"exp": now.Add(-goTime.Minute).Unix(),
+				}))
+
+				return request
+			},
+			err: UnauthenticatedError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := test.authenticator
+			if service == nil {
+				service = authenticator
+			}
+
+			caller, err := service.Authenticate(context.Background(), test.request())
+			if err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if caller.Identity != test.identity {
+				t.Errorf("identity %s, want %s", caller.Identity, test.identity)
+			}
+
+			if !reflect.DeepEqual(caller.Roles, test.roles) {
+				t.Errorf("roles %v, want %v", caller.Roles, test.roles)
+			}
+		})
+	}
+}
+
+func TestHmacNonces(t *testing.T) {
+	method := newHmac(nil, goTime.Minute)
+	now := time.NowUTC()
+
+	tests := []struct {
+		nonce      string
+		expiration goTime.Time
+		remembered bool
+	}{
+		{nonce: "first", expiration: now.Add(goTime.Minute), remembered: true},
+		{nonce: "first", expiration: now.Add(goTime.Minute)},
+		{nonce: "expired", expiration: now.Add(-goTime.Second), remembered: true},
+		{nonce: "expired", expiration: now.Add(goTime.Minute), remembered: true},
+	}
+
+	for index, test := range tests {
+		t.Run(fmt.Sprintf("%d %s", index, test.nonce), func(t *testing.T) {
+			if remembered := method.remember(test.nonce, test.expiration); remembered != test.remembered {
+				t.Errorf("remembered %t, want %t", remembered, test.remembered)
+			}
+		})
+	}
+}
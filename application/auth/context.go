@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"context"
+	"github.com/Diez37/passwords/domain"
+)
+
+type callerKey struct{}
+
+// WithCaller return context carrying the authenticated caller of the request
+func WithCaller(ctx context.Context, caller *domain.Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext return the caller passed by WithCaller, nil outside of authenticated requests
+func CallerFromContext(ctx context.Context) *domain.Caller {
+	if caller, ok := ctx.Value(callerKey{}).(*domain.Caller); ok {
+		return caller
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	goTime "time"
+)
+
+const (
+	IdHeaderName        = "X-Auth-Id"
+	TimestampHeaderName = "X-Auth-Timestamp"
+	SignatureHeaderName = "X-Auth-Signature"
+	NonceHeaderName     = "X-Auth-Nonce"
+
+	// nonceSize maximum length of nonces, remembered nonces are bounded by it
+	nonceSize = 128
+)
+
+// hmacSigned authentication by requests signed with shared secrets, nonces of accepted requests are remembered
+// until their timestamps leave the skew, so a captured request cannot be replayed to this instance
+type hmacSigned struct {
+	secrets map[string]string
+	skew    goTime.Duration
+
+	mutex  *sync.Mutex
+	nonces map[string]goTime.Time
+	pruned goTime.Time
+}
+
+func newHmac(secrets map[string]string, skew goTime.Duration) *hmacSigned {
+	return &hmacSigned{secrets: secrets, skew: skew, mutex: &sync.Mutex{}, nonces: map[string]goTime.Time{}}
+}
+
+func (method *hmacSigned) authenticate(_ context.Context, request *http.Request) (string, []domain.Role, bool, error) {
+	signature := request.Header.Get(SignatureHeaderName)
+	if signature == "" {
+		return "", nil, false, nil
+	}
+
+	identity := request.Header.Get(IdHeaderName)
+
+	secret, ok := method.secrets[identity]
+	if !ok {
+		return "", nil, false, UnauthenticatedError
+	}
+
+	timestamp := request.Header.Get(TimestampHeaderName)
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", nil, false, UnauthenticatedError
+	}
+
+	signedAt := goTime.Unix(seconds, 0)
+
+	if skew := time.NowUTC().Sub(signedAt); skew > method.skew || skew < -method.skew {
+		return "", nil, false, UnauthenticatedError
+	}
+
+	nonce := request.Header.Get(NonceHeaderName)
+	if nonce == "" || len(nonce) > nonceSize {
+		return "", nil, false, UnauthenticatedError
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	// the body is read again by handlers
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, request.Method, request.URL.RequestURI(), timestamp, nonce, body))) {
+		return "", nil, false, UnauthenticatedError
+	}
+
+	// only signed nonces are remembered, so forged requests cannot fill the cache
+	if !method.remember(identity+"\n"+nonce, signedAt.Add(method.skew)) {
+		return "", nil, false, UnauthenticatedError
+	}
+
+	return identity, nil, true, nil
+}
+
+// remember saving of the nonce until the expiration, false if the nonce is already saved
+func (method *hmacSigned) remember(nonce string, expiration goTime.Time) bool {
+	method.mutex.Lock()
+	defer method.mutex.Unlock()
+
+	now := time.NowUTC()
+
+	// expired nonces are pruned at most once per skew, requests with them are rejected by the timestamp
+	if now.Sub(method.pruned) > method.skew {
+		for saved, savedExpiration := range method.nonces {
+			if now.After(savedExpiration) {
+				delete(method.nonces, saved)
+			}
+		}
+
+		method.pruned = now
+	}
+
+	if savedExpiration, ok := method.nonces[nonce]; ok && !now.After(savedExpiration) {
+		return false
+	}
+
+	method.nonces[nonce] = expiration
+
+	return true
+}
+
+// Sign signature of the request for the SignatureHeaderName header, HMAC-SHA256 over lines of the method,
+// the uri, the unix timestamp, the nonce and the body
+func Sign(secret string, method string, uri string, timestamp string, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s\n%s\n%s\n%s\n", method, uri, timestamp, nonce)))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
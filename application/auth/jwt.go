@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/go-http-utils/headers"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+const (
+	bearerPrefix = "Bearer "
+
+	rs256Algorithm = "RS256"
+	es256Algorithm = "ES256"
+)
+
+var (
+	InvalidJwksError = errors.New("invalid JSON Web Key Set")
+)
+
+// jsonWebKey key of the JWKS file, only public parts of RSA and P-256 keys are read
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+	Roles     []string        `json:"roles"`
+}
+
+// jwt authentication by bearer tokens verified with keys of the local JWKS file
+type jwt struct {
+	keys     map[string]crypto.PublicKey
+	issuer   string
+	audience string
+}
+
+func newJwt(file string, issuer string, audience string) (*jwt, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	set := struct {
+		Keys []*jsonWebKey `json:"keys"`
+	}{}
+
+	if err := json.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("%w: %s", InvalidJwksError, err)
+	}
+
+	method := &jwt{keys: map[string]crypto.PublicKey{}, issuer: issuer, audience: audience}
+
+	for _, key := range set.Keys {
+		publicKey, err := key.public()
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %s: %s", InvalidJwksError, key.Kid, err)
+		}
+
+		method.keys[key.Kid] = publicKey
+	}
+
+	return method, nil
+}
+
+func (key *jsonWebKey) public() (crypto.PublicKey, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeInt(key.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(key.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if key.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", key.Crv)
+		}
+
+		x, err := decodeInt(key.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(key.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %s", key.Kty)
+}
+
+func (method *jwt) authenticate(_ context.Context, request *http.Request) (string, []domain.Role, bool, error) {
+	authorization := request.Header.Get(headers.Authorization)
+	if !strings.HasPrefix(authorization, bearerPrefix) {
+		return "", nil, false, nil
+	}
+
+	claims, err := method.verify(strings.TrimPrefix(authorization, bearerPrefix))
+	if err != nil {
+		return "", nil, false, UnauthenticatedError
+	}
+
+	roles := make([]domain.Role, len(claims.Roles))
+	for index, role := range claims.Roles {
+		roles[index] = domain.Role(role)
+	}
+
+	return claims.Subject, roles, true, nil
+}
+
+// verify checking of the signature and registered claims of the compact serialized token
+func (method *jwt) verify(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	header := &jwtHeader{}
+	if err := decodeSegment(parts[0], header); err != nil {
+		return nil, err
+	}
+
+	key, ok := method.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", header.Kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	// the algorithm of the header must match the type of the key, otherwise tokens signed with
+	// the public key as the HMAC secret would pass
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != rs256Algorithm {
+			return nil, fmt.Errorf("algorithm %s of rsa key", header.Alg)
+		}
+
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, err
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != es256Algorithm {
+			return nil, fmt.Errorf("algorithm %s of ec key", header.Alg)
+		}
+
+		if len(signature) != 64 {
+			return nil, errors.New("malformed signature")
+		}
+
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(key, digest[:], r, s) {
+			return nil, errors.New("invalid signature")
+		}
+	}
+
+	claims := &jwtClaims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, err
+	}
+
+	now := float64(time.NowUTC().Unix())
+
+	if claims.ExpiresAt == nil || now >= *claims.ExpiresAt {
+		return nil, errors.New("expired token")
+	}
+
+	if claims.NotBefore != nil && now < *claims.NotBefore {
+		return nil, errors.New("token is not valid yet")
+	}
+
+	if method.issuer != "" && claims.Issuer != method.issuer {
+		return nil, fmt.Errorf("issuer %s", claims.Issuer)
+	}
+
+	if method.audience != "" && !claims.hasAudience(method.audience) {
+		return nil, errors.New("token is not issued for the audience")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("token without subject")
+	}
+
+	return claims, nil
+}
+
+// hasAudience whether the aud claim, a string or an array of strings, contains the audience
+func (claims *jwtClaims) hasAudience(audience string) bool {
+	var single string
+	if err := json.Unmarshal(claims.Audience, &single); err == nil {
+		return single == audience
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(claims.Audience, &multiple); err != nil {
+		return false
+	}
+
+	for _, value := range multiple {
+		if value == audience {
+			return true
+		}
+	}
+
+	return false
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	content, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, value)
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	content, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(content), nil
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"github.com/Diez37/passwords/domain"
+	"net/http"
+)
+
+// mtls authentication by client certificates verified on the tls handshake
+type mtls struct {
+	identities map[string]string
+}
+
+func newMtls(identities map[string]string) *mtls {
+	return &mtls{identities: identities}
+}
+
+func (method *mtls) authenticate(_ context.Context, request *http.Request) (string, []domain.Role, bool, error) {
+	if request.TLS == nil || len(request.TLS.VerifiedChains) == 0 || len(request.TLS.VerifiedChains[0]) == 0 {
+		return "", nil, false, nil
+	}
+
+	certificate := request.TLS.VerifiedChains[0][0]
+
+	// alternative names are preferred to the common name, which is deprecated for identification
+	var names []string
+	for _, uri := range certificate.URIs {
+		names = append(names, "uri:"+uri.String())
+	}
+
+	for _, name := range certificate.DNSNames {
+		names = append(names, "dns:"+name)
+	}
+
+	for _, address := range certificate.EmailAddresses {
+		names = append(names, "email:"+address)
+	}
+
+	if certificate.Subject.CommonName != "" {
+		names = append(names, "cn:"+certificate.Subject.CommonName)
+	}
+
+	for _, name := range names {
+		if identity, ok := method.identities[name]; ok {
+			return identity, nil, true, nil
+		}
+	}
+
+	// the certificate is trusted but does not identify a caller, other credentials of the request may
+	return "", nil, false, nil
+}
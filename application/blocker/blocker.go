@@ -2,29 +2,85 @@ package blocker
 
 import (
 	"context"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/outbox"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/metrics"
 	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"sync"
+	goTime "time"
 )
 
+const (
+	PendingStatus  Status = "pending"
+	DisabledStatus Status = "disabled"
+	NotFoundStatus Status = "not_found"
+	FailedStatus   Status = "failed"
+)
+
+// Status state of the password disabling requested through the Blocker
+type Status string
+
 type Blocker interface {
 	Add(context.Context, uuid.UUID)
 	Block(context.Context) error
+
+	// Disable disabling of the password immediately, db.RecordNotFoundError is returned for unknown uuid
+	Disable(context.Context, uuid.UUID) error
+
+	// DisableByLogin disabling of all active passwords of the login immediately except passed uuids,
+	// return count of disabled passwords
+	DisableByLogin(ctx context.Context, login uuid.UUID, except ...uuid.UUID) (int64, error)
+
+	// Status return the state of the last disabling of the password, false if nothing is known about it
+	Status(context.Context, uuid.UUID) (Status, bool)
+}
+
+type state struct {
+	status   Status
+	updateAt goTime.Time
 }
 
 type blocker struct {
 	mutex *sync.Mutex
 
-	uuids []uuid.UUID
+	uuids    []uuid.UUID
+	queued   map[uuid.UUID]bool
+	statuses map[uuid.UUID]*state
 
+	config     *config.Blocker
 	repository repository.Repository
+	auditor    audit.Auditor
+	outbox     outbox.Outbox
 	tracer     trace.Tracer
+	metrics    *metrics.Metrics
 }
 
-func NewBlocker(repository repository.Repository, tracer trace.Tracer) Blocker {
-	return &blocker{repository: repository, tracer: tracer, mutex: &sync.Mutex{}}
+func NewBlocker(
+	config *config.Blocker,
+	repository repository.Repository,
+	tracer trace.Tracer,
+	auditor audit.Auditor,
+	outbox outbox.Outbox,
+	metrics *metrics.Metrics,
+) Blocker {
+	return &blocker{
+		config:     config,
+		repository: repository,
+		auditor:    auditor,
+		outbox:     outbox,
+		tracer:     tracer,
+		metrics:    metrics,
+		mutex:      &sync.Mutex{},
+		queued:     map[uuid.UUID]bool{},
+		statuses:   map[uuid.UUID]*state{},
+	}
 }
 
 func (service *blocker) Add(ctx context.Context, uuid uuid.UUID) {
@@ -36,7 +92,16 @@ func (service *blocker) Add(ctx context.Context, uuid uuid.UUID) {
 	service.mutex.Lock()
 	defer service.mutex.Unlock()
 
+	service.statuses[uuid] = &state{status: PendingStatus, updateAt: time.NowUTC()}
+
+	if service.queued[uuid] {
+		return
+	}
+
 	service.uuids = append(service.uuids, uuid)
+	service.queued[uuid] = true
+
+	service.metrics.BlockerQueue.Set(float64(len(service.uuids)))
 }
 
 func (service *blocker) Block(ctx context.Context) error {
@@ -47,6 +112,8 @@ func (service *blocker) Block(ctx context.Context) error {
 
 	service.mutex.Lock()
 
+	service.cleanStatuses()
+
 	if len(service.uuids) == 0 {
 		service.mutex.Unlock()
 		return nil
@@ -54,8 +121,202 @@ func (service *blocker) Block(ctx context.Context) error {
 
 	uuids := service.uuids
 	service.uuids = []uuid.UUID{}
+	service.queued = map[uuid.UUID]bool{}
+	service.metrics.BlockerQueue.Set(0)
 	service.mutex.Unlock()
 
-	_, err := service.repository.DisableByUuids(ctx, uuids...)
+	passwords, err := service.repository.FindByUuids(ctx, uuids...)
+	if err != nil && err != db.RecordNotFoundError {
+		service.metrics.BlockerFailures.Inc()
+		service.setStatus(FailedStatus, uuids...)
+		return err
+	}
+
+	exists := map[uuid.UUID]*repository.Password{}
+	for _, password := range passwords {
+		exists[password.Uuid] = password
+	}
+
+	var active, inactive, notFound []uuid.UUID
+	for _, uuid := range uuids {
+		switch password := exists[uuid]; {
+		case password == nil:
+			notFound = append(notFound, uuid)
+		case password.Disabled:
+			inactive = append(inactive, uuid)
+		default:
+			active = append(active, uuid)
+		}
+	}
+
+	service.setStatus(NotFoundStatus, notFound...)
+	service.setStatus(DisabledStatus, inactive...)
+
+	if len(active) == 0 {
+		return nil
+	}
+
+	var disabled []uuid.UUID
+
+	// every password is disabled by its own statement to know which of them were still active,
+	// events are recorded only for them
+	err = service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		for _, uuid := range active {
+			if _, err := service.repository.DisableByUuids(ctx, uuid); err == db.RecordNotFoundError {
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			if err := service.outbox.Append(ctx, domain.DisabledAction, exists[uuid].Login, uuid); err != nil {
+				return err
+			}
+
+			disabled = append(disabled, uuid)
+		}
+
+		return nil
+	})
+	if err != nil {
+		service.metrics.BlockerFailures.Inc()
+		service.setStatus(FailedStatus, active...)
+		return err
+	}
+
+	service.setStatus(DisabledStatus, active...)
+
+	for _, uuid := range disabled {
+		service.auditor.Record(ctx, domain.DisabledAction, exists[uuid].Login, uuid)
+	}
+
+	return nil
+}
+
+func (service *blocker) Disable(ctx context.Context, uuid uuid.UUID) error {
+	ctx, span := service.tracer.Start(ctx, "Disable")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "blocker"))
+
+	disabled := false
+
+	password, err := service.repository.FindByUuid(ctx, uuid)
+	if err == nil && !password.Disabled {
+		err = service.outbox.Transaction(ctx, func(ctx context.Context) error {
+			// the password disabled concurrently is not an error, but it is not disabled by this call
+			if _, err := service.repository.DisableByUuids(ctx, uuid); err == db.RecordNotFoundError {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			disabled = true
+
+			return service.outbox.Append(ctx, domain.DisabledAction, password.Login, uuid)
+		})
+	}
+
+	switch err {
+	case nil:
+		service.setStatus(DisabledStatus, uuid)
+
+		if disabled {
+			service.auditor.Record(ctx, domain.DisabledAction, password.Login, uuid)
+		}
+	case db.RecordNotFoundError:
+		service.setStatus(NotFoundStatus, uuid)
+	default:
+		service.setStatus(FailedStatus, uuid)
+	}
+
 	return err
 }
+
+func (service *blocker) DisableByLogin(ctx context.Context, login uuid.UUID, except ...uuid.UUID) (int64, error) {
+	ctx, span := service.tracer.Start(ctx, "DisableByLogin")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "blocker"))
+
+	excepted := map[uuid.UUID]bool{}
+	for _, uuid := range except {
+		excepted[uuid] = true
+	}
+
+	var disabled []uuid.UUID
+	count := int64(0)
+
+	// the passwords are read before disabling only for events, the disabling is still done by one statement
+	err := service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		passwords, err := service.repository.FindActiveByLogin(ctx, login)
+		if err != nil && err != db.RecordNotFoundError {
+			return err
+		}
+
+		if count, err = service.repository.DisableByLogin(ctx, login, except...); err != nil {
+			return err
+		}
+
+		for _, password := range passwords {
+			if excepted[password.Uuid] {
+				continue
+			}
+
+			if err := service.outbox.Append(ctx, domain.DisabledAction, login, password.Uuid); err != nil {
+				return err
+			}
+
+			disabled = append(disabled, password.Uuid)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, uuid := range disabled {
+		service.auditor.Record(ctx, domain.DisabledAction, login, uuid)
+	}
+
+	return count, nil
+}
+
+func (service *blocker) Status(ctx context.Context, uuid uuid.UUID) (Status, bool) {
+	_, span := service.tracer.Start(ctx, "Status")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "blocker"))
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	state, ok := service.statuses[uuid]
+	if !ok {
+		return "", false
+	}
+
+	return state.status, true
+}
+
+func (service *blocker) setStatus(status Status, uuids ...uuid.UUID) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	now := time.NowUTC()
+
+	for _, uuid := range uuids {
+		service.statuses[uuid] = &state{status: status, updateAt: now}
+	}
+}
+
+// cleanStatuses forgetting of final statuses older than config.Blocker.StatusLifetime, must be called under mutex
+func (service *blocker) cleanStatuses() {
+	now := time.NowUTC()
+
+	for uuid, state := range service.statuses {
+		if state.status != PendingStatus && now.Sub(state.updateAt) > service.config.StatusLifetime {
+			delete(service.statuses, uuid)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package blocker
+
+import (
+	"context"
+	"errors"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/outbox"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"reflect"
+	"testing"
+	goTime "time"
+)
+
+var testDisableError = errors.New("db is unavailable")
+
+// testRepository in-memory passwords of all logins, disabling fails with disableErr if it is set,
+// statements of disabling by uuids are counted
+type testRepository struct {
+	repository.Repository
+
+	passwords  []*repository.Password
+	disableErr error
+	disables   int
+}
+
+func (fake *testRepository) FindByUuid(_ context.Context, uuid uuid.UUID) (*repository.Password, error) {
+	for _, password := range fake.passwords {
+		if password.Uuid == uuid {
+			return password, nil
+		}
+	}
+
+	return nil, db.RecordNotFoundError
+}
+
+func (fake *testRepository) FindByUuids(ctx context.Context, uuids ...uuid.UUID) ([]*repository.Password, error) {
+	var passwords []*repository.Password
+	for _, uuid := range uuids {
+		if password, err := fake.FindByUuid(ctx, uuid); err == nil {
+			passwords = append(passwords, password)
+		}
+	}
+
+	if len(passwords) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return passwords, nil
+}
+
+func (fake *testRepository) DisableByUuids(ctx context.Context, uuids ...uuid.UUID) (bool, error) {
+	fake.disables++
+
+	if fake.disableErr != nil {
+		return false, fake.disableErr
+	}
+
+	disabled := false
+	for _, uuid := range uuids {
+		if password, err := fake.FindByUuid(ctx, uuid); err == nil && !password.Disabled {
+			password.Disabled = true
+			disabled = true
+		}
+	}
+
+	if !disabled {
+		return false, db.RecordNotFoundError
+	}
+
+	return true, nil
+}
+
+func (fake *testRepository) FindActiveByLogin(_ context.Context, login uuid.UUID) ([]*repository.Password, error) {
+	var passwords []*repository.Password
+	for _, password := range fake.passwords {
+		if password.Login == login && !password.Disabled {
+			passwords = append(passwords, password)
+		}
+	}
+
+	if len(passwords) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return passwords, nil
+}
+
+func (fake *testRepository) DisableByLogin(ctx context.Context, login uuid.UUID, except ...uuid.UUID) (int64, error) {
+	if fake.disableErr != nil {
+		return 0, fake.disableErr
+	}
+
+	passwords, _ := fake.FindActiveByLogin(ctx, login)
+	count := int64(0)
+
+	for _, password := range passwords {
+		excepted := false
+		for _, uuid := range except {
+			excepted = excepted || password.Uuid == uuid
+		}
+
+		if !excepted {
+			password.Disabled = true
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// testAuditor recorded actions of the audit log
+type testAuditor struct {
+	audit.Auditor
+
+	actions []domain.AuditAction
+}
+
+func (fake *testAuditor) Record(_ context.Context, action domain.AuditAction, _ uuid.UUID, _ uuid.UUID) {
+	fake.actions = append(fake.actions, action)
+}
+
+// testOutbox appended actions, actions of failed transactions are dropped
+type testOutbox struct {
+	outbox.Outbox
+
+	actions []domain.AuditAction
+}
+
+func (fake *testOutbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	actions := fake.actions
+
+	if err := fn(ctx); err != nil {
+		fake.actions = actions
+		return err
+	}
+
+	return nil
+}
+
+func (fake *testOutbox) Append(_ context.Context, action domain.AuditAction, _ uuid.UUID, _ uuid.UUID) error {
+	fake.actions = append(fake.actions, action)
+	return nil
+}
+
+func newTestBlocker(repository *testRepository) (Blocker, *testAuditor, *testOutbox) {
+	auditor, outbox := &testAuditor{}, &testOutbox{}
+
+	return NewBlocker(
+		&config.Blocker{StatusLifetime: goTime.Hour},
+		repository,
+		trace.NewNoopTracerProvider().Tracer(""),
+		auditor,
+		outbox,
+		&metrics.Metrics{
+			BlockerQueue:    prometheus.NewGauge(prometheus.GaugeOpts{Name: "queue"}),
+			BlockerFailures: prometheus.NewCounter(prometheus.CounterOpts{Name: "failures"}),
+		},
+	), auditor, outbox
+}
+
+// testStored active passwords of one login
+func testStored(count int) []*repository.Password {
+	login := uuid.New()
+	passwords := make([]*repository.Password, count)
+
+	for index := range passwords {
+		passwords[index] = &repository.Password{Uuid: uuid.New(), Login: login}
+	}
+
+	return passwords
+}
+
+func TestBlockerDisable(t *testing.T) {
+	tests := []struct {
+		name       string
+		unknown    bool
+		disabled   bool
+		disableErr error
+		err        error
+		status     Status
+		audit      []domain.AuditAction
+	}{
+		{name: "stored password", status: DisabledStatus, audit: []domain.AuditAction{domain.DisabledAction}},
+		{name: "already disabled password", disabled: true, status: DisabledStatus},
+		{name: "unknown password", unknown: true, err: db.RecordNotFoundError, status: NotFoundStatus},
+		{name: "failed disabling", disableErr: testDisableError, err: testDisableError, status: FailedStatus},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stored := testStored(1)
+			stored[0].Disabled = test.disabled
+
+			service, auditor, outbox := newTestBlocker(&testRepository{passwords: stored, disableErr: test.disableErr})
+			ctx := context.Background()
+
+			target := stored[0].Uuid
+			if test.unknown {
+				target = uuid.New()
+			}
+
+			if err := service.Disable(ctx, target); err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if status, ok := service.Status(ctx, target); !ok || status != test.status {
+				t.Errorf("status %s, want %s", status, test.status)
+			}
+
+			if !reflect.DeepEqual(auditor.actions, test.audit) {
+				t.Errorf("audit %v, want %v", auditor.actions, test.audit)
+			}
+
+			if !reflect.DeepEqual(outbox.actions, test.audit) {
+				t.Errorf("outbox %v, want %v", outbox.actions, test.audit)
+			}
+		})
+	}
+}
+
+func TestBlockerBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     int
+		disabled   int
+		unknown    int
+		twice      bool
+		disableErr error
+		err        error
+		status     Status
+		audit      int
+	}{
+		{name: "empty queue"},
+		{name: "stored passwords", stored: 2, unknown: 1, status: DisabledStatus, audit: 2},
+		{name: "already disabled password", stored: 2, disabled: 1, status: DisabledStatus, audit: 1},
+		{name: "password queued twice", stored: 2, twice: true, status: DisabledStatus, audit: 2},
+		{name: "failed disabling", stored: 2, disableErr: testDisableError, err: testDisableError, status: FailedStatus},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stored := testStored(test.stored)
+			for _, password := range stored[:test.disabled] {
+				password.Disabled = true
+			}
+
+			fake := &testRepository{passwords: stored, disableErr: test.disableErr}
+			service, auditor, outbox := newTestBlocker(fake)
+			ctx := context.Background()
+
+			var unknown []uuid.UUID
+			for index := 0; index < test.unknown; index++ {
+				unknown = append(unknown, uuid.New())
+			}
+
+			for _, password := range stored {
+				service.Add(ctx, password.Uuid)
+			}
+
+			for _, uuid := range unknown {
+				service.Add(ctx, uuid)
+			}
+
+			if test.twice {
+				service.Add(ctx, stored[0].Uuid)
+			}
+
+			for _, password := range stored {
+				if status, _ := service.Status(ctx, password.Uuid); status != PendingStatus {
+					t.Fatalf("status %s before blocking, want %s", status, PendingStatus)
+				}
+			}
+
+			if err := service.Block(ctx); err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			for _, password := range stored {
+				if status, _ := service.Status(ctx, password.Uuid); status != test.status {
+					t.Errorf("status %s, want %s", status, test.status)
+				}
+
+				if password.Disabled != (test.status == DisabledStatus) {
+					t.Errorf("disabled %t, want %t", password.Disabled, test.status == DisabledStatus)
+				}
+			}
+
+			for _, uuid := range unknown {
+				if status, _ := service.Status(ctx, uuid); status != NotFoundStatus {
+					t.Errorf("status %s of unknown password, want %s", status, NotFoundStatus)
+				}
+			}
+
+			if len(auditor.actions) != test.audit || len(outbox.actions) != test.audit {
+				t.Errorf("%d audit and %d outbox actions, want %d", len(auditor.actions), len(outbox.actions), test.audit)
+			}
+
+			// only active passwords are disabled and each of them once
+			if test.err == nil && fake.disables != test.audit {
+				t.Errorf("%d disabling statements, want %d", fake.disables, test.audit)
+			}
+
+			// the queue is emptied even by the failed blocking
+			if err := service.Block(ctx); err != nil {
+				t.Errorf("error %v of the repeated blocking", err)
+			}
+		})
+	}
+}
+
+func TestBlockerDisableByLogin(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     int
+		disabled   int
+		except     int
+		disableErr error
+		err        error
+		count      int64
+	}{
+		{name: "login without passwords"},
+		{name: "active passwords", stored: 3, disabled: 1, count: 2},
+		{name: "excepted passwords stay active", stored: 3, except: 2, count: 1},
+		{name: "failed disabling", stored: 2, disableErr: testDisableError, err: testDisableError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			login := uuid.New()
+			stored := append(testStored(test.stored), testStored(1)...)
+
+			for index, password := range stored[:test.stored] {
+				password.Login = login
+				password.Disabled = index < test.disabled
+			}
+
+			var except []uuid.UUID
+			for _, password := range stored[:test.except] {
+				except = append(except, password.Uuid)
+			}
+
+			service, auditor, outbox := newTestBlocker(&testRepository{passwords: stored, disableErr: test.disableErr})
+
+			count, err := service.DisableByLogin(context.Background(), login, except...)
+			if err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if count != test.count {
+				t.Errorf("%d disabled passwords, want %d", count, test.count)
+			}
+
+			if len(auditor.actions) != int(test.count) || len(outbox.actions) != int(test.count) {
+				t.Errorf("%d audit and %d outbox actions, want %d", len(auditor.actions), len(outbox.actions), test.count)
+			}
+
+			// the password of another login is not touched
+			if other := stored[len(stored)-1]; other.Disabled {
+				t.Errorf("password of another login is disabled")
+			}
+		})
+	}
+}
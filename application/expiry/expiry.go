@@ -0,0 +1,110 @@
+package expiry
+
+import (
+	"context"
+	"github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"sort"
+	goTime "time"
+)
+
+const (
+	day = 24 * goTime.Hour
+
+	// limit count of passwords read at once for notification
+	limit = 100
+)
+
+type Expiry interface {
+	// Notify sending of events about passwords reached thresholds of config.Expiry.Thresholds,
+	// only the closest threshold is sent for every password and every threshold is sent once
+	Notify(ctx context.Context) error
+}
+
+type expiry struct {
+	config     *config.Expiry
+	notifier   Notifier
+	repository repository.Repository
+	tracer     trace.Tracer
+}
+
+func NewExpiry(config *config.Expiry, notifier Notifier, repository repository.Repository, tracer trace.Tracer) Expiry {
+	return &expiry{config: config, notifier: notifier, repository: repository, tracer: tracer}
+}
+
+func (service *expiry) Notify(ctx context.Context) error {
+	ctx, span := service.tracer.Start(ctx, "Notify")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "expiry"))
+
+	thresholds := append([]int{}, service.config.Thresholds...)
+	sort.Ints(thresholds)
+
+	// the closest threshold goes first, so passwords reached it are not notified about farther ones
+	for _, threshold := range thresholds {
+		if threshold <= 0 {
+			continue
+		}
+
+		if err := service.notifyThreshold(ctx, threshold); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *expiry) notifyThreshold(ctx context.Context, threshold int) error {
+	ctx, span := service.tracer.Start(ctx, "notifyThreshold")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("service", "expiry"),
+		attribute.Int("threshold", threshold),
+	)
+
+	until := time.NowUTC().Add(goTime.Duration(threshold) * day)
+
+	for {
+		models, err := service.repository.Unnotified(ctx, until, int64(threshold), limit)
+		if err == db.RecordNotFoundError {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, model := range models {
+			// another instance has already taken the password
+			ok, err := service.repository.Notice(ctx, model.Uuid, model.ExpiryNotice, int64(threshold))
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				continue
+			}
+
+			err = service.notifier.Notify(ctx, &domain.ExpiryEvent{Password: password.ToDomain(model), Threshold: threshold})
+			if err != nil {
+				if _, noticeErr := service.repository.Notice(ctx, model.Uuid, int64(threshold), model.ExpiryNotice); noticeErr != nil {
+					return noticeErr
+				}
+
+				return err
+			}
+		}
+
+		if len(models) < limit {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"reflect"
+	"testing"
+	goTime "time"
+)
+
+var testNotifyError = errors.New("notifier is unavailable")
+
+// testRepository in-memory passwords of all logins, found passwords are copies like rows read from db
+type testRepository struct {
+	repository.Repository
+
+	passwords []*repository.Password
+}
+
+func (fake *testRepository) Unnotified(_ context.Context, until goTime.Time, notice int64, limit uint) ([]*repository.Password, error) {
+	var passwords []*repository.Password
+	for _, password := range fake.passwords {
+		if password.Disabled || password.ValidUntil.After(until) || uint(len(passwords)) >= limit {
+			continue
+		}
+
+		if password.ExpiryNotice == 0 || password.ExpiryNotice > notice {
+			found := *password
+			passwords = append(passwords, &found)
+		}
+	}
+
+	if len(passwords) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return passwords, nil
+}
+
+func (fake *testRepository) Notice(_ context.Context, uuid uuid.UUID, current int64, notice int64) (bool, error) {
+	for _, password := range fake.passwords {
+		if password.Uuid == uuid && password.ExpiryNotice == current {
+			password.ExpiryNotice = notice
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// testNotifier thresholds of sent events by uuids of passwords
+type testNotifier struct {
+	err    error
+	events map[uuid.UUID][]int
+}
+
+func (fake *testNotifier) Notify(_ context.Context, event *domain.ExpiryEvent) error {
+	if fake.err != nil {
+		return fake.err
+	}
+
+	fake.events[event.Password.Uuid] = append(fake.events[event.Password.Uuid], event.Threshold)
+
+	return nil
+}
+
+// testExpiring active password which validity ends in days, the notification about notice is already sent
+func testExpiring(days int, notice int64) *repository.Password {
+	validUntil := time.NowUTC().Add(goTime.Duration(days)*day - goTime.Hour)
+
+	return &repository.Password{Uuid: uuid.New(), Login: uuid.New(), ValidUntil: &validUntil, ExpiryNotice: notice}
+}
+
+func TestExpiryNotify(t *testing.T) {
+	tests := []struct {
+		name       string
+		thresholds []int
+		passwords  []*repository.Password
+		err        error
+		events     [][]int
+		notices    []int64
+	}{
+		{
+			name:       "closest threshold is sent",
+			thresholds: []int{7, 1, 30},
+			passwords:  []*repository.Password{testExpiring(1, 0), testExpiring(5, 0), testExpiring(20, 0), testExpiring(40, 0)},
+			events:     [][]int{{1}, {7}, {30}, nil},
+			notices:    []int64{1, 7, 30, 0},
+		},
+		{
+			name:       "sent threshold is not repeated",
+			thresholds: []int{1, 7},
+			passwords:  []*repository.Password{testExpiring(1, 1), testExpiring(5, 7)},
+			events:     [][]int{nil, nil},
+			notices:    []int64{1, 7},
+		},
+		{
+			name:       "closer threshold is sent after a farther one",
+			thresholds: []int{1, 7},
+			passwords:  []*repository.Password{testExpiring(1, 7)},
+			events:     [][]int{{1}},
+			notices:    []int64{1},
+		},
+		{
+			name:       "not positive thresholds are skipped",
+			thresholds: []int{0, -1},
+			passwords:  []*repository.Password{testExpiring(-1, 0)},
+			events:     [][]int{nil},
+			notices:    []int64{0},
+		},
+		{
+			name:       "notice is restored on failed notification",
+			thresholds: []int{7},
+			passwords:  []*repository.Password{testExpiring(5, 30)},
+			err:        testNotifyError,
+			events:     [][]int{nil},
+			notices:    []int64{30},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notifier := &testNotifier{err: test.err, events: map[uuid.UUID][]int{}}
+
+			service := NewExpiry(
+				&config.Expiry{Thresholds: test.thresholds},
+				notifier,
+				&testRepository{passwords: test.passwords},
+				trace.NewNoopTracerProvider().Tracer(""),
+			)
+
+			// the second run sends nothing new
+			for run := 0; run < 2; run++ {
+				if err := service.Notify(context.Background()); err != test.err {
+					t.Fatalf("error %v, want %v", err, test.err)
+				}
+			}
+
+			for index, password := range test.passwords {
+				if events := notifier.events[password.Uuid]; !reflect.DeepEqual(events, test.events[index]) {
+					t.Errorf("password %d: events %v, want %v", index, events, test.events[index])
+				}
+
+				if password.ExpiryNotice != test.notices[index] {
+					t.Errorf("password %d: notice %d, want %d", index, password.ExpiryNotice, test.notices[index])
+				}
+			}
+		})
+	}
+}
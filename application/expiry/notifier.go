@@ -0,0 +1,41 @@
+package expiry
+
+import (
+	"context"
+	"github.com/Diez37/passwords/domain"
+	"github.com/diez37/go-packages/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Notifier delivery of expiry events, implementations are passed to NewExpiry
+type Notifier interface {
+	Notify(ctx context.Context, event *domain.ExpiryEvent) error
+}
+
+type logNotifier struct {
+	logger log.Logger
+	tracer trace.Tracer
+}
+
+// NewLogNotifier notifier writing of events to the log
+func NewLogNotifier(logger log.Logger, tracer trace.Tracer) Notifier {
+	return &logNotifier{logger: logger, tracer: tracer}
+}
+
+func (notifier *logNotifier) Notify(ctx context.Context, event *domain.ExpiryEvent) error {
+	_, span := notifier.tracer.Start(ctx, "Notify")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("notifier", "log"))
+
+	notifier.logger.Infof(
+		"expiry: password %s of login %s expires at %s (threshold %d days)",
+		event.Password.Uuid,
+		event.Password.Login,
+		event.Password.ValidUntil,
+		event.Threshold,
+	)
+
+	return nil
+}
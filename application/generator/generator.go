@@ -0,0 +1,237 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"math/big"
+	"os"
+	"strings"
+)
+
+const (
+	RandomKind     Kind = "random"
+	PassphraseKind Kind = "passphrase"
+	PinKind        Kind = "pin"
+
+	AlphabetDefault    = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789!#$%&*+-=?@^_"
+	LengthDefault      = 16
+	WordsDefault       = 6
+	SeparatorDefault   = "-"
+	GroupsDefault      = 1
+	GroupLengthDefault = 6
+
+	lengthMaximum = 256
+	wordsMaximum  = 32
+	groupsMaximum = 16
+)
+
+var (
+	InvalidProfileError = errors.New("invalid profile of password generation")
+
+	//go:embed words.txt
+	wordsDefault []byte
+)
+
+// Kind type of the generated password
+type Kind string
+
+// Profile settings of the password generation, zero values are replaced with defaults of the kind
+type Profile struct {
+	Kind Kind
+
+	// Length and Alphabet are used by RandomKind, repeated symbols of Alphabet are counted once
+	Length   int
+	Alphabet string
+
+	// Words is used by PassphraseKind
+	Words int
+
+	// Groups and GroupLength are used by PinKind
+	Groups      int
+	GroupLength int
+
+	// Separator between words of PassphraseKind or groups of PinKind
+	Separator string
+}
+
+type Generator interface {
+	Generate(context.Context, *Profile) (string, error)
+}
+
+type generator struct {
+	words  []string
+	tracer trace.Tracer
+}
+
+func NewGenerator(config *config.Generator, tracer trace.Tracer) (Generator, error) {
+	content := wordsDefault
+
+	if config.WordsFile != "" {
+		file, err := os.ReadFile(config.WordsFile)
+		if err != nil {
+			return nil, err
+		}
+
+		content = file
+	}
+
+	var words []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			words = append(words, word)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(words) < 2 {
+		return nil, errors.New("generator: words list must contain at least two words")
+	}
+
+	return &generator{words: words, tracer: tracer}, nil
+}
+
+func (service *generator) Generate(ctx context.Context, profile *Profile) (string, error) {
+	_, span := service.tracer.Start(ctx, "Generate")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("service", "generator"),
+		attribute.String("kind", string(profile.Kind)),
+	)
+
+	switch profile.Kind {
+	case RandomKind, "":
+		return service.random(profile)
+	case PassphraseKind:
+		return service.passphrase(profile)
+	case PinKind:
+		return service.pin(profile)
+	}
+
+	return "", InvalidProfileError
+}
+
+func (service *generator) random(profile *Profile) (string, error) {
+	length := orDefault(profile.Length, LengthDefault)
+	alphabet := distinct(profile.Alphabet)
+	if len(alphabet) == 0 {
+		alphabet = []rune(AlphabetDefault)
+	}
+
+	if length > lengthMaximum || len(alphabet) < 2 {
+		return "", InvalidProfileError
+	}
+
+	password := make([]rune, length)
+	for index := range password {
+		position, err := randomInt(len(alphabet))
+		if err != nil {
+			return "", err
+		}
+
+		password[index] = alphabet[position]
+	}
+
+	return string(password), nil
+}
+
+func (service *generator) passphrase(profile *Profile) (string, error) {
+	count := orDefault(profile.Words, WordsDefault)
+	if count > wordsMaximum {
+		return "", InvalidProfileError
+	}
+
+	separator := profile.Separator
+	if separator == "" {
+		separator = SeparatorDefault
+	}
+
+	words := make([]string, count)
+	for index := range words {
+		position, err := randomInt(len(service.words))
+		if err != nil {
+			return "", err
+		}
+
+		words[index] = service.words[position]
+	}
+
+	return strings.Join(words, separator), nil
+}
+
+func (service *generator) pin(profile *Profile) (string, error) {
+	groups := orDefault(profile.Groups, GroupsDefault)
+	groupLength := orDefault(profile.GroupLength, GroupLengthDefault)
+
+	if groups > groupsMaximum || groupLength > lengthMaximum/groups {
+		return "", InvalidProfileError
+	}
+
+	separator := profile.Separator
+	if separator == "" {
+		separator = SeparatorDefault
+	}
+
+	parts := make([]string, groups)
+	for index := range parts {
+		digits := make([]byte, groupLength)
+		for position := range digits {
+			digit, err := randomInt(10)
+			if err != nil {
+				return "", err
+			}
+
+			digits[position] = byte('0' + digit)
+		}
+
+		parts[index] = string(digits)
+	}
+
+	return strings.Join(parts, separator), nil
+}
+
+// distinct symbols of the alphabet in the order of their first occurrence,
+// repeated symbols would make them more probable than others
+func distinct(alphabet string) []rune {
+	seen := map[rune]bool{}
+
+	var symbols []rune
+	for _, symbol := range alphabet {
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	return symbols
+}
+
+// randomInt uniform random number in [0, max) from crypto/rand
+func randomInt(max int) (int, error) {
+	number, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
+	}
+
+	return int(number.Int64()), nil
+}
+
+func orDefault(value int, _default int) int {
+	if value <= 0 {
+		return _default
+	}
+
+	return value
+}
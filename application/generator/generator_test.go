@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"context"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"go.opentelemetry.io/otel/trace"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestNewGenerator(t *testing.T) {
+	directory := t.TempDir()
+
+	for file, content := range map[string]string{"words.txt": "alpha\n\n beta \n", "word.txt": "alpha\n"} {
+		if err := ioutil.WriteFile(filepath.Join(directory, file), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		file  string
+		words []string
+		err   bool
+	}{
+		{name: "built-in words"},
+		{name: "words of the file", file: "words.txt", words: []string{"alpha", "beta"}},
+		{name: "one word", file: "word.txt", err: true},
+		{name: "missing file", file: "missing.txt", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			generatorConfig := &config.Generator{}
+			if test.file != "" {
+				generatorConfig.WordsFile = filepath.Join(directory, test.file)
+			}
+
+			service, err := NewGenerator(generatorConfig, trace.NewNoopTracerProvider().Tracer(""))
+			if (err != nil) != test.err {
+				t.Fatalf("error %v, want error %t", err, test.err)
+			}
+
+			if err != nil || test.words == nil {
+				return
+			}
+
+			if words := service.(*generator).words; len(words) != len(test.words) || words[0] != test.words[0] || words[1] != test.words[1] {
+				t.Errorf("words %v, want %v", words, test.words)
+			}
+		})
+	}
+}
+
+func TestGeneratorGenerate(t *testing.T) {
+	service, err := NewGenerator(&config.Generator{}, trace.NewNoopTracerProvider().Tracer(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		profile *Profile
+		pattern string
+		err     error
+	}{
+		{name: "random by default", profile: &Profile{}, pattern: `^\S{16}$`},
+		{name: "random of the alphabet", profile: &Profile{Kind: RandomKind, Length: 8, Alphabet: "ab"}, pattern: `^[ab]{8}$`},
+		{name: "random of one symbol", profile: &Profile{Kind: RandomKind, Alphabet: "a"}, err: InvalidProfileError},
+		{name: "random of one repeated symbol", profile: &Profile{Kind: RandomKind, Alphabet: "aa"}, err: InvalidProfileError},
+		{name: "random of repeated symbols", profile: &Profile{Kind: RandomKind, Length: 8, Alphabet: "abab"}, pattern: `^[ab]{8}$`},
+		{name: "random over the maximum", profile: &Profile{Kind: RandomKind, Length: lengthMaximum + 1}, err: InvalidProfileError},
+		{name: "passphrase", profile: &Profile{Kind: PassphraseKind}, pattern: `^[a-z]+(-[a-z]+){5}$`},
+		{name: "passphrase with the separator", profile: &Profile{Kind: PassphraseKind, Words: 3, Separator: " "}, pattern: `^[a-z]+( [a-z]+){2}$`},
+		{name: "passphrase over the maximum", profile: &Profile{Kind: PassphraseKind, Words: wordsMaximum + 1}, err: InvalidProfileError},
+		{name: "pin", profile: &Profile{Kind: PinKind}, pattern: `^\d{6}$`},
+		{name: "pin of groups", profile: &Profile{Kind: PinKind, Groups: 3, GroupLength: 4}, pattern: `^\d{4}-\d{4}-\d{4}$`},
+		{name: "pin over the maximum", profile: &Profile{Kind: PinKind, Groups: 2, GroupLength: lengthMaximum}, err: InvalidProfileError},
+		{name: "unknown kind", profile: &Profile{Kind: "emoji"}, err: InvalidProfileError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			password, err := service.Generate(context.Background(), test.profile)
+			if err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if err == nil && !regexp.MustCompile(test.pattern).MatchString(password) {
+				t.Errorf("password %q does not match %s", password, test.pattern)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/metrics"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -11,12 +12,13 @@ import (
 )
 
 type crypto struct {
-	config *config.Hash
-	tracer trace.Tracer
+	config  *config.Hash
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
 }
 
-func NewCrypto(config *config.Hash, tracer trace.Tracer) Hasher {
-	return &crypto{config: config, tracer: tracer}
+func NewCrypto(config *config.Hash, tracer trace.Tracer, metrics *metrics.Metrics) Hasher {
+	return &crypto{config: config, tracer: tracer, metrics: metrics}
 }
 
 func (hasher *crypto) Password(ctx context.Context, login uuid.UUID, password string) (string, error) {
@@ -25,6 +27,8 @@ func (hasher *crypto) Password(ctx context.Context, login uuid.UUID, password st
 
 	span.SetAttributes(attribute.String("service", "crypto"))
 
+	defer hasher.metrics.Hash(metrics.BcryptAlgorithm).ObserveDuration()
+
 	hash, err := bcrypt.GenerateFromPassword(hasher.makePassword(ctx, login, password), bcrypt.DefaultCost)
 	if err != nil {
 		return "", err
@@ -39,6 +43,8 @@ func (hasher *crypto) Check(ctx context.Context, login uuid.UUID, password strin
 
 	span.SetAttributes(attribute.String("service", "crypto"))
 
+	defer hasher.metrics.Hash(metrics.BcryptAlgorithm).ObserveDuration()
+
 	return bcrypt.CompareHashAndPassword([]byte(hash), hasher.makePassword(ctx, login, password)) == nil
 }
 
@@ -0,0 +1,209 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/golang-migrate/migrate/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"sort"
+	"strings"
+	"sync"
+	goTime "time"
+)
+
+const (
+	MigrationsCheck = "migrations"
+	DbCheck         = "db"
+	RepeaterCheck   = "repeater"
+)
+
+type Health interface {
+	// Live return the report of the liveness, the process is up while it answers
+	Live(ctx context.Context) *domain.HealthReport
+
+	// Ready return the report of the readiness: migrations are applied, the db answers
+	// and every expected task of the repeater completed a cycle recently
+	Ready(ctx context.Context) *domain.HealthReport
+
+	// Migrated marking that migrations are applied
+	Migrated()
+
+	// Expect registering of the task of the repeater running every interval
+	Expect(task string, interval goTime.Duration)
+
+	// Beat marking that the task of the repeater completed a cycle without errors
+	Beat(task string)
+}
+
+// beat last completed cycle of the task of the repeater
+type beat struct {
+	interval goTime.Duration
+	at       goTime.Time
+}
+
+type health struct {
+	mutex *sync.Mutex
+
+	migrated bool
+	beats    map[string]*beat
+
+	config     *config.Health
+	repository repository.Pinger
+	migrator   *migrate.Migrate
+	tracer     trace.Tracer
+}
+
+func NewHealth(
+	config *config.Health,
+	repository repository.Pinger,
+	migrator *migrate.Migrate,
+	tracer trace.Tracer,
+) Health {
+	return &health{
+		mutex:      &sync.Mutex{},
+		beats:      map[string]*beat{},
+		config:     config,
+		repository: repository,
+		migrator:   migrator,
+		tracer:     tracer,
+	}
+}
+
+func (service *health) Live(ctx context.Context) *domain.HealthReport {
+	_, span := service.tracer.Start(ctx, "Live")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "health"))
+
+	return &domain.HealthReport{Status: domain.UpHealthStatus, Checks: []*domain.HealthCheck{}}
+}
+
+func (service *health) Ready(ctx context.Context) *domain.HealthReport {
+	ctx, span := service.tracer.Start(ctx, "Ready")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "health"))
+
+	report := &domain.HealthReport{
+		Status: domain.UpHealthStatus,
+		Checks: []*domain.HealthCheck{
+			service.checkMigrations(),
+			service.checkDb(ctx),
+			service.checkRepeater(),
+		},
+	}
+
+	for _, check := range report.Checks {
+		if check.Status != domain.UpHealthStatus {
+			report.Status = domain.DownHealthStatus
+		}
+	}
+
+	return report
+}
+
+func (service *health) Migrated() {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	service.migrated = true
+}
+
+func (service *health) Expect(task string, interval goTime.Duration) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	// the task is given the same time for the first cycle as for the next ones
+	service.beats[task] = &beat{interval: interval, at: time.NowUTC()}
+}
+
+func (service *health) Beat(task string) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if beat, ok := service.beats[task]; ok {
+		beat.at = time.NowUTC()
+	}
+}
+
+func (service *health) checkMigrations() *domain.HealthCheck {
+	service.mutex.Lock()
+	migrated := service.migrated
+	service.mutex.Unlock()
+
+	if !migrated {
+		return down(MigrationsCheck, "migrations are not applied yet")
+	}
+
+	version, dirty, err := service.migrator.Version()
+	if err != nil {
+		return down(MigrationsCheck, err.Error())
+	}
+
+	if dirty {
+		return down(MigrationsCheck, fmt.Sprintf("migration %d is failed, the schema is dirty", version))
+	}
+
+	return up(MigrationsCheck, fmt.Sprintf("version %d", version))
+}
+
+func (service *health) checkDb(ctx context.Context) *domain.HealthCheck {
+	if err := service.repository.Ping(ctx); err != nil {
+		return down(DbCheck, err.Error())
+	}
+
+	return up(DbCheck, "ping is answered")
+}
+
+// checkRepeater checking of every expected task, tasks share one loop of the repeater, so a slow task
+// delays the others and each of them is measured by its own interval
+func (service *health) checkRepeater() *domain.HealthCheck {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	tasks := make([]string, 0, len(service.beats))
+	for task := range service.beats {
+		tasks = append(tasks, task)
+	}
+
+	sort.Strings(tasks)
+
+	now := time.NowUTC()
+
+	var late, completed []string
+	for _, task := range tasks {
+		beat := service.beats[task]
+
+		passed := now.Sub(beat.at)
+		limit := beat.interval * goTime.Duration(service.config.RepeaterIntervals)
+
+		if passed > limit {
+			late = append(late, fmt.Sprintf("%s cycle is completed %s ago, limit is %s", task, passed.Truncate(goTime.Second), limit))
+		}
+
+		completed = append(completed, fmt.Sprintf("%s cycle is completed %s ago", task, passed.Truncate(goTime.Second)))
+	}
+
+	if len(late) > 0 {
+		return down(RepeaterCheck, strings.Join(late, ", "))
+	}
+
+	if len(completed) == 0 {
+		return down(RepeaterCheck, "repeater is not started yet")
+	}
+
+	return up(RepeaterCheck, strings.Join(completed, ", "))
+}
+
+func up(name string, message string) *domain.HealthCheck {
+	return &domain.HealthCheck{Name: name, Status: domain.UpHealthStatus, Message: message}
+}
+
+func down(name string, message string) *domain.HealthCheck {
+	return &domain.HealthCheck{Name: name, Status: domain.DownHealthStatus, Message: message}
+}
@@ -0,0 +1,101 @@
+package health
+
+import (
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+	"testing"
+	goTime "time"
+)
+
+func TestHealthRepeater(t *testing.T) {
+	type task struct {
+		name     string
+		interval goTime.Duration
+		ago      goTime.Duration
+	}
+
+	tests := []struct {
+		name    string
+		tasks   []task
+		beats   []string
+		status  domain.HealthStatus
+		message string
+	}{
+		{
+			name:    "repeater is not started",
+			status:  domain.DownHealthStatus,
+			message: "repeater is not started yet",
+		},
+		{
+			name: "every task is in time",
+			tasks: []task{
+				{name: "block", interval: 10 * goTime.Second, ago: 20 * goTime.Second},
+				{name: "checkpoint", interval: goTime.Hour, ago: 2 * goTime.Hour},
+			},
+			status: domain.UpHealthStatus,
+		},
+		{
+			name: "late task with a long interval is measured by its own interval",
+			tasks: []task{
+				{name: "block", interval: 10 * goTime.Second},
+				{name: "checkpoint", interval: goTime.Hour, ago: 4 * goTime.Hour},
+			},
+			status:  domain.DownHealthStatus,
+			message: "checkpoint cycle",
+		},
+		{
+			name: "late block while other tasks beat",
+			tasks: []task{
+				{name: "block", interval: 10 * goTime.Second, ago: goTime.Minute},
+				{name: "outbox", interval: 5 * goTime.Second},
+			},
+			status:  domain.DownHealthStatus,
+			message: "block cycle",
+		},
+		{
+			name: "beat of the late task",
+			tasks: []task{
+				{name: "block", interval: 10 * goTime.Second, ago: goTime.Minute},
+			},
+			beats:  []string{"block"},
+			status: domain.UpHealthStatus,
+		},
+		{
+			name: "beat of an unexpected task is ignored",
+			tasks: []task{
+				{name: "block", interval: 10 * goTime.Second, ago: goTime.Minute},
+			},
+			beats:   []string{"webhook"},
+			status:  domain.DownHealthStatus,
+			message: "block cycle",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := NewHealth(&config.Health{RepeaterIntervals: 3}, nil, nil, trace.NewNoopTracerProvider().Tracer("")).(*health)
+
+			for _, task := range test.tasks {
+				service.Expect(task.name, task.interval)
+				service.beats[task.name].at = time.NowUTC().Add(-task.ago)
+			}
+
+			for _, task := range test.beats {
+				service.Beat(task)
+			}
+
+			check := service.checkRepeater()
+
+			if check.Status != test.status {
+				t.Errorf("status %s, want %s: %s", check.Status, test.status, check.Message)
+			}
+
+			if !strings.Contains(check.Message, test.message) {
+				t.Errorf("message %q, want %q", check.Message, test.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+)
+
+const (
+	Sha1Algorithm   = "SHA1"
+	Sha256Algorithm = "SHA256"
+	Sha512Algorithm = "SHA512"
+)
+
+// otpCode generation of HOTP value by RFC 4226, TOTP uses number of the time step as counter (RFC 6238)
+func otpCode(secret []byte, algorithm string, counter int64, digits int) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, uint64(counter))
+
+	mac := hmac.New(hashFunc(algorithm), secret)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for index := 0; index < digits; index++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
+
+func hashFunc(algorithm string) func() hash.Hash {
+	switch algorithm {
+	case Sha256Algorithm:
+		return sha256.New
+	case Sha512Algorithm:
+		return sha512.New
+	}
+
+	return sha1.New
+}
+
+// secretSize recommended size of the secret for the algorithm
+func secretSize(algorithm string) int {
+	switch algorithm {
+	case Sha256Algorithm:
+		return sha256.Size
+	case Sha512Algorithm:
+		return sha512.Size
+	}
+
+	return sha1.Size
+}
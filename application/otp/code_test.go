@@ -0,0 +1,44 @@
+package otp
+
+import "testing"
+
+func TestOtpCode(t *testing.T) {
+	// test vectors of RFC 4226 appendix D and RFC 6238 appendix B
+	tests := []struct {
+		name      string
+		secret    string
+		algorithm string
+		counter   int64
+		digits    int
+		code      string
+	}{
+		{name: "hotp counter 0", secret: "12345678901234567890", algorithm: Sha1Algorithm, counter: 0, digits: 6, code: "755224"},
+		{name: "hotp counter 1", secret: "12345678901234567890", algorithm: Sha1Algorithm, counter: 1, digits: 6, code: "287082"},
+		{name: "hotp counter 9", secret: "12345678901234567890", algorithm: Sha1Algorithm, counter: 9, digits: 6, code: "520489"},
+		{name: "totp sha1", secret: "12345678901234567890", algorithm: Sha1Algorithm, counter: 59 / 30, digits: 8, code: "94287082"},
+		{
+			name:      "totp sha256",
+			secret:    "12345678901234567890123456789012",
+			algorithm: Sha256Algorithm,
+			counter:   59 / 30,
+			digits:    8,
+			code:      "46119246",
+		},
+		{
+			name:      "totp sha512",
+			secret:    "1234567890123456789012345678901234567890123456789012345678901234",
+			algorithm: Sha512Algorithm,
+			counter:   59 / 30,
+			digits:    8,
+			code:      "90693936",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := otpCode([]byte(test.secret), test.algorithm, test.counter, test.digits); code != test.code {
+				t.Errorf("code %s, want %s", code, test.code)
+			}
+		})
+	}
+}
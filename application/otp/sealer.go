@@ -0,0 +1,55 @@
+package otp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// sealer encryption of secrets with AES-256-GCM, additional data binds ciphertext to its owner
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(key string) (*sealer, error) {
+	sum := sha256.Sum256([]byte(key))
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sealer{aead: aead}, nil
+}
+
+func (sealer *sealer) seal(plaintext []byte, additional []byte) (string, error) {
+	nonce := make([]byte, sealer.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(sealer.aead.Seal(nonce, nonce, plaintext, additional)), nil
+}
+
+func (sealer *sealer) open(ciphertext string, additional []byte) ([]byte, error) {
+	content, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) < sealer.aead.NonceSize() {
+		return nil, errors.New("otp: ciphertext is too short")
+	}
+
+	nonce := content[:sealer.aead.NonceSize()]
+
+	return sealer.aead.Open(nil, nonce, content[sealer.aead.NonceSize():], additional)
+}
@@ -0,0 +1,201 @@
+package otp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"net/url"
+	goTime "time"
+)
+
+var (
+	InvalidKindError = errors.New("invalid kind of otp")
+)
+
+type Service interface {
+	// Enroll generation and saving of new secret for otp.Login, previous secrets of the login are disabled,
+	// return otpauth:// uri of the secret
+	Enroll(ctx context.Context, otp *domain.Otp) (string, error)
+
+	// Verify checking of the code by the active secret of the login, every code is accepted only once
+	Verify(ctx context.Context, login uuid.UUID, code string) (bool, error)
+
+	// Disable disabling of all secrets of the login, return count of disabled secrets
+	Disable(ctx context.Context, login uuid.UUID) (int64, error)
+}
+
+type otp struct {
+	config     *config.Otp
+	sealer     *sealer
+	repository repository.OtpRepository
+	tracer     trace.Tracer
+}
+
+func NewOtp(config *config.Otp, repository repository.OtpRepository, tracer trace.Tracer) (Service, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	sealer, err := newSealer(config.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &otp{config: config, sealer: sealer, repository: repository, tracer: tracer}, nil
+}
+
+func (service *otp) Enroll(ctx context.Context, otp *domain.Otp) (string, error) {
+	ctx, span := service.tracer.Start(ctx, "Enroll")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "otp"))
+
+	if otp.Kind == "" {
+		otp.Kind = domain.TotpKind
+	}
+
+	if otp.Kind != domain.TotpKind && otp.Kind != domain.HotpKind {
+		return "", InvalidKindError
+	}
+
+	if otp.Algorithm == "" {
+		otp.Algorithm = Sha1Algorithm
+	}
+
+	if otp.Digits == 0 {
+		otp.Digits = service.config.Digits
+	}
+
+	otp.Uuid = uuid.New()
+	otp.Period = service.config.Period
+	otp.Counter = 0
+	otp.Disabled = false
+
+	otp.Secret = make([]byte, secretSize(otp.Algorithm))
+	if _, err := rand.Read(otp.Secret); err != nil {
+		return "", err
+	}
+
+	secret, err := service.sealer.seal(otp.Secret, additional(otp.Uuid, otp.Login))
+	if err != nil {
+		return "", err
+	}
+
+	model, err := service.repository.InsertOtp(ctx, &repository.Otp{
+		Uuid:      otp.Uuid,
+		Login:     otp.Login,
+		Kind:      string(otp.Kind),
+		Secret:    secret,
+		Algorithm: otp.Algorithm,
+		Digits:    otp.Digits,
+		Period:    int64(otp.Period / goTime.Second),
+		Counter:   otp.Counter,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	otp.CreatedAt = model.CreatedAt
+
+	return service.uri(otp), nil
+}
+
+func (service *otp) Verify(ctx context.Context, login uuid.UUID, code string) (bool, error) {
+	ctx, span := service.tracer.Start(ctx, "Verify")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "otp"))
+
+	model, err := service.repository.FindActiveOtpByLogin(ctx, login)
+	if err != nil && err != db.RecordNotFoundError {
+		return false, err
+	}
+
+	if err == db.RecordNotFoundError {
+		return false, nil
+	}
+
+	secret, err := service.sealer.open(model.Secret, additional(model.Uuid, model.Login))
+	if err != nil {
+		return false, err
+	}
+
+	var from, to int64
+
+	switch domain.OtpKind(model.Kind) {
+	case domain.TotpKind:
+		step := time.NowUTC().Unix() / model.Period
+
+		// steps not newer than the last accepted one are rejected, so the code cannot be replayed
+		from = step - int64(service.config.Skew)
+		if from <= model.Counter {
+			from = model.Counter + 1
+		}
+
+		to = step + int64(service.config.Skew)
+	case domain.HotpKind:
+		from = model.Counter
+		to = model.Counter + int64(service.config.LookAhead)
+	default:
+		return false, InvalidKindError
+	}
+
+	for counter := from; counter <= to; counter++ {
+		if subtle.ConstantTimeCompare([]byte(code), []byte(otpCode(secret, model.Algorithm, counter, model.Digits))) != 1 {
+			continue
+		}
+
+		next := counter
+		if domain.OtpKind(model.Kind) == domain.HotpKind {
+			next = counter + 1
+		}
+
+		// a concurrent verification of the same code has already moved the counter
+		return service.repository.UpdateOtpCounter(ctx, model.Uuid, model.Counter, next)
+	}
+
+	return false, nil
+}
+
+func (service *otp) Disable(ctx context.Context, login uuid.UUID) (int64, error) {
+	ctx, span := service.tracer.Start(ctx, "Disable")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "otp"))
+
+	return service.repository.DisableOtpByLogin(ctx, login)
+}
+
+func (service *otp) uri(otp *domain.Otp) string {
+	label := url.PathEscape(fmt.Sprintf("%s:%s", service.config.Issuer, otp.Login.String()))
+
+	query := url.Values{}
+	query.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(otp.Secret))
+	query.Set("issuer", service.config.Issuer)
+	query.Set("algorithm", otp.Algorithm)
+	query.Set("digits", fmt.Sprint(otp.Digits))
+
+	if otp.Kind == domain.HotpKind {
+		query.Set("counter", fmt.Sprint(otp.Counter))
+	} else {
+		query.Set("period", fmt.Sprint(int64(otp.Period/goTime.Second)))
+	}
+
+	return fmt.Sprintf("otpauth://%s/%s?%s", otp.Kind, label, query.Encode())
+}
+
+// additional data of the encrypted secret, a secret copied to another record cannot be decrypted
+func additional(uuid uuid.UUID, login uuid.UUID) []byte {
+	return []byte(uuid.String() + login.String())
+}
@@ -0,0 +1,136 @@
+package otp
+
+import (
+	"context"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+	goTime "time"
+)
+
+// testRepository in-memory secret of one login
+type testRepository struct {
+	otp *repository.Otp
+}
+
+func (fake *testRepository) FindActiveOtpByLogin(context.Context, uuid.UUID) (*repository.Otp, error) {
+	if fake.otp == nil || fake.otp.Disabled {
+		return nil, db.RecordNotFoundError
+	}
+
+	return fake.otp, nil
+}
+
+func (fake *testRepository) InsertOtp(_ context.Context, otp *repository.Otp) (*repository.Otp, error) {
+	now := time.NowUTC()
+	otp.CreatedAt = &now
+	fake.otp = otp
+
+	return otp, nil
+}
+
+func (fake *testRepository) DisableOtpByLogin(context.Context, uuid.UUID) (int64, error) {
+	if fake.otp == nil || fake.otp.Disabled {
+		return 0, nil
+	}
+
+	fake.otp.Disabled = true
+
+	return 1, nil
+}
+
+func (fake *testRepository) UpdateOtpCounter(_ context.Context, _ uuid.UUID, current int64, counter int64) (bool, error) {
+	if fake.otp.Counter != current {
+		return false, nil
+	}
+
+	fake.otp.Counter = counter
+
+	return true, nil
+}
+
+func testOtpConfig() *config.Otp {
+	return &config.Otp{
+		Key:       "otp key",
+		Issuer:    config.OtpIssuerDefault,
+		Skew:      config.OtpSkewDefault,
+		LookAhead: config.OtpLookAheadDefault,
+		Period:    config.OtpPeriodDefault,
+		Digits:    config.OtpDigitsDefault,
+	}
+}
+
+func TestNewOtp(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(otpConfig *config.Otp)
+		err    error
+	}{
+		{name: "valid config", change: func(*config.Otp) {}},
+		{name: "without key", change: func(otpConfig *config.Otp) { otpConfig.Key = "" }, err: config.MissingOtpKeyError},
+		{name: "zero period", change: func(otpConfig *config.Otp) { otpConfig.Period = 0 }, err: config.InvalidOtpPeriodError},
+		{name: "zero digits", change: func(otpConfig *config.Otp) { otpConfig.Digits = 0 }, err: config.InvalidOtpDigitsError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			otpConfig := testOtpConfig()
+			test.change(otpConfig)
+
+			if _, err := NewOtp(otpConfig, &testRepository{}, trace.NewNoopTracerProvider().Tracer("")); err != test.err {
+				t.Errorf("error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestOtpVerify(t *testing.T) {
+	step := time.NowUTC().Unix() / int64(config.OtpPeriodDefault/goTime.Second)
+
+	tests := []struct {
+		name  string
+		kind  domain.OtpKind
+		codes []int64
+		oks   []bool
+	}{
+		{name: "totp current step", kind: domain.TotpKind, codes: []int64{step}, oks: []bool{true}},
+		{name: "totp replayed code", kind: domain.TotpKind, codes: []int64{step, step}, oks: []bool{true, false}},
+		{name: "totp previous step after the current one", kind: domain.TotpKind, codes: []int64{step, step - 1}, oks: []bool{true, false}},
+		{name: "totp step out of the skew", kind: domain.TotpKind, codes: []int64{step + 5}, oks: []bool{false}},
+		{name: "hotp counters in order", kind: domain.HotpKind, codes: []int64{0, 1}, oks: []bool{true, true}},
+		{name: "hotp replayed code", kind: domain.HotpKind, codes: []int64{0, 0}, oks: []bool{true, false}},
+		{name: "hotp look-ahead", kind: domain.HotpKind, codes: []int64{5, 20}, oks: []bool{true, false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			service, err := NewOtp(testOtpConfig(), &testRepository{}, trace.NewNoopTracerProvider().Tracer(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			otp := &domain.Otp{Login: uuid.New(), Kind: test.kind}
+			if _, err := service.Enroll(ctx, otp); err != nil {
+				t.Fatal(err)
+			}
+
+			for index, counter := range test.codes {
+				ok, err := service.Verify(ctx, otp.Login, otpCode(otp.Secret, otp.Algorithm, counter, otp.Digits))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if ok != test.oks[index] {
+					t.Errorf("code %d of counter %d accepted %t, want %t", index, counter, ok, test.oks[index])
+				}
+			}
+		})
+	}
+}
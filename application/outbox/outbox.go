@@ -0,0 +1,231 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/diez37/go-packages/log"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	goTime "time"
+)
+
+const (
+	// limit count of events relayed at once
+	limit = 100
+)
+
+var (
+	UnknownSinkError = errors.New("unknown outbox sink")
+	RelayError       = errors.New("outbox relay to some of sinks is failed")
+)
+
+// Sink receiver of relayed events, an event may be received again after a failure,
+// so the sink must skip events with already received OutboxEvent.Uuid
+type Sink interface {
+	Send(ctx context.Context, event *domain.OutboxEvent) error
+}
+
+type Outbox interface {
+	// Transaction running of the credential change and appending of its events in one transaction
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// Append saving of the event, in the transaction of the ctx if it is started by Transaction,
+	// nothing is saved without config.Outbox.Sinks, no sink would relay and delete the event
+	Append(ctx context.Context, action domain.AuditAction, login uuid.UUID, password uuid.UUID) error
+
+	// Relay delivering of saved events to every sink of config.Outbox.Sinks in order of saving,
+	// events delivered to all sinks are deleted, RelayError is returned if a sink failed
+	Relay(ctx context.Context) error
+}
+
+// payload body of events sent to sinks
+type payload struct {
+	Id         uuid.UUID    `json:"id"`
+	Event      string       `json:"event"`
+	Login      uuid.UUID    `json:"login"`
+	Password   uuid.UUID    `json:"password"`
+	RequestId  string       `json:"request_id"`
+	OccurredAt *goTime.Time `json:"occurred_at"`
+}
+
+type outbox struct {
+	sinks map[string]Sink
+
+	config     *config.Outbox
+	repository repository.OutboxRepository
+	logger     log.Logger
+	tracer     trace.Tracer
+}
+
+func NewOutbox(
+	config *config.Outbox,
+	repository repository.OutboxRepository,
+	logger log.Logger,
+	tracer trace.Tracer,
+	sinks map[string]Sink,
+) (Outbox, error) {
+	for _, name := range config.Sinks {
+		if _, ok := sinks[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", UnknownSinkError, name)
+		}
+	}
+
+	return &outbox{sinks: sinks, config: config, repository: repository, logger: logger, tracer: tracer}, nil
+}
+
+func (service *outbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, span := service.tracer.Start(ctx, "Transaction")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "outbox"))
+
+	return service.repository.Transaction(ctx, fn)
+}
+
+func (service *outbox) Append(ctx context.Context, action domain.AuditAction, login uuid.UUID, password uuid.UUID) error {
+	ctx, span := service.tracer.Start(ctx, "Append")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("service", "outbox"),
+		attribute.String("action", string(action)),
+	)
+
+	if len(service.config.Sinks) == 0 {
+		return nil
+	}
+
+	now := time.NowUTC()
+
+	event := &repository.OutboxEvent{
+		Uuid:      uuid.New(),
+		Action:    string(action),
+		Login:     login,
+		Password:  password,
+		RequestId: audit.RequestId(ctx),
+		CreatedAt: &now,
+	}
+
+	content, err := json.Marshal(&payload{
+		Id:         event.Uuid,
+		Event:      fmt.Sprintf("password.%s", action),
+		Login:      login,
+		Password:   password,
+		RequestId:  event.RequestId,
+		OccurredAt: event.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	event.Payload = string(content)
+
+	return service.repository.AppendOutbox(ctx, event)
+}
+
+func (service *outbox) Relay(ctx context.Context) error {
+	ctx, span := service.tracer.Start(ctx, "Relay")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "outbox"))
+
+	if len(service.config.Sinks) == 0 {
+		return nil
+	}
+
+	delivered := int64(-1)
+
+	var failed error
+
+	for _, name := range service.config.Sinks {
+		cursor, err := service.relay(ctx, name)
+		if err != nil {
+			service.logger.Errorf("outbox: relay to %s is stopped on event after %d: %s", name, cursor, err)
+			failed = RelayError
+		}
+
+		if delivered == -1 || cursor < delivered {
+			delivered = cursor
+		}
+	}
+
+	if delivered > 0 {
+		if err := service.repository.DeleteOutbox(ctx, delivered); err != nil {
+			return err
+		}
+	}
+
+	return failed
+}
+
+// relay delivering of events to the sink after its cursor, return the id of the last delivered event,
+// the cursor is saved after every batch, so a failure redelivers at most one batch
+func (service *outbox) relay(ctx context.Context, name string) (int64, error) {
+	ctx, span := service.tracer.Start(ctx, "relay")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("sink", name))
+
+	cursor, err := service.repository.OutboxCursor(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		events, err := service.repository.NextOutbox(ctx, cursor, limit)
+		if err == db.RecordNotFoundError {
+			return cursor, nil
+		}
+
+		if err != nil {
+			return cursor, err
+		}
+
+		last := cursor
+
+		for _, event := range events {
+			if err = service.sinks[name].Send(ctx, toDomain(event)); err != nil {
+				break
+			}
+
+			last = event.Id
+		}
+
+		if last != cursor {
+			if err := service.repository.SaveOutboxCursor(ctx, name, last); err != nil {
+				return cursor, err
+			}
+
+			cursor = last
+		}
+
+		if err != nil {
+			return cursor, err
+		}
+
+		if len(events) < limit {
+			return cursor, nil
+		}
+	}
+}
+
+func toDomain(model *repository.OutboxEvent) *domain.OutboxEvent {
+	return &domain.OutboxEvent{
+		Uuid:      model.Uuid,
+		Action:    domain.AuditAction(model.Action),
+		Login:     model.Login,
+		Password:  model.Password,
+		RequestId: model.RequestId,
+		Payload:   model.Payload,
+		CreatedAt: model.CreatedAt,
+	}
+}
@@ -0,0 +1,189 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+var testSinkError = errors.New("sink is unavailable")
+
+// testRepository in-memory events and cursors of sinks
+type testRepository struct {
+	events  []*repository.OutboxEvent
+	cursors map[string]int64
+	id      int64
+}
+
+func (fake *testRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (fake *testRepository) AppendOutbox(_ context.Context, events ...*repository.OutboxEvent) error {
+	for _, event := range events {
+		fake.id++
+		event.Id = fake.id
+		fake.events = append(fake.events, event)
+	}
+
+	return nil
+}
+
+func (fake *testRepository) NextOutbox(_ context.Context, after int64, limit uint) ([]*repository.OutboxEvent, error) {
+	var events []*repository.OutboxEvent
+	for _, event := range fake.events {
+		if event.Id > after && uint(len(events)) < limit {
+			events = append(events, event)
+		}
+	}
+
+	if len(events) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return events, nil
+}
+
+func (fake *testRepository) DeleteOutbox(_ context.Context, id int64) error {
+	var events []*repository.OutboxEvent
+	for _, event := range fake.events {
+		if event.Id > id {
+			events = append(events, event)
+		}
+	}
+
+	fake.events = events
+
+	return nil
+}
+
+func (fake *testRepository) OutboxCursor(_ context.Context, sink string) (int64, error) {
+	return fake.cursors[sink], nil
+}
+
+func (fake *testRepository) SaveOutboxCursor(_ context.Context, sink string, id int64) error {
+	fake.cursors[sink] = id
+	return nil
+}
+
+// testSink received events, the sink fails after accepting of accept events if accept is not negative
+type testSink struct {
+	accept int
+	events []*domain.OutboxEvent
+}
+
+func (sink *testSink) Send(_ context.Context, event *domain.OutboxEvent) error {
+	if sink.accept >= 0 && len(sink.events) >= sink.accept {
+		return testSinkError
+	}
+
+	sink.events = append(sink.events, event)
+
+	return nil
+}
+
+func TestNewOutbox(t *testing.T) {
+	tests := []struct {
+		name  string
+		sinks []string
+		err   bool
+	}{
+		{name: "without sinks"},
+		{name: "known sink", sinks: []string{config.LogOutboxSink}},
+		{name: "unknown sink", sinks: []string{"queue"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewOutbox(
+				&config.Outbox{Sinks: test.sinks},
+				&testRepository{},
+				logrus.New(),
+				trace.NewNoopTracerProvider().Tracer(""),
+				map[string]Sink{config.LogOutboxSink: &testSink{accept: -1}},
+			)
+			if errors.Is(err, UnknownSinkError) != test.err {
+				t.Errorf("error %v, want error %t", err, test.err)
+			}
+		})
+	}
+}
+
+func TestOutboxRelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		sinks    []string
+		accept   int
+		saved    int
+		log      int
+		file     int
+		retained int
+		err      error
+	}{
+		{name: "without sinks nothing is saved", accept: -1},
+		{name: "delivered events are deleted", sinks: []string{config.LogOutboxSink}, accept: -1, saved: 3, log: 3},
+		{
+			name:     "events of a failed sink are retained",
+			sinks:    []string{config.LogOutboxSink, config.FileOutboxSink},
+			accept:   1,
+			saved:    3,
+			log:      3,
+			file:     1,
+			retained: 2,
+			err:      RelayError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &testRepository{cursors: map[string]int64{}}
+			log, file := &testSink{accept: -1}, &testSink{accept: test.accept}
+
+			service, err := NewOutbox(
+				&config.Outbox{Sinks: test.sinks},
+				repository,
+				logrus.New(),
+				trace.NewNoopTracerProvider().Tracer(""),
+				map[string]Sink{config.LogOutboxSink: log, config.FileOutboxSink: file},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+
+			for _, action := range []domain.AuditAction{domain.AddAction, domain.DisabledAction, domain.ConsumedAction} {
+				if err := service.Append(ctx, action, uuid.New(), uuid.New()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if saved := int(repository.id); saved != test.saved {
+				t.Errorf("%d saved events, want %d", saved, test.saved)
+			}
+
+			if err := service.Relay(ctx); err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if len(log.events) != test.log {
+				t.Errorf("%d events of the log sink, want %d", len(log.events), test.log)
+			}
+
+			if len(file.events) != test.file {
+				t.Errorf("%d events of the file sink, want %d", len(file.events), test.file)
+			}
+
+			if len(repository.events) != test.retained {
+				t.Errorf("%d retained events, want %d", len(repository.events), test.retained)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package outbox
+
+import (
+	"context"
+	"github.com/Diez37/passwords/domain"
+	"github.com/diez37/go-packages/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+)
+
+type logSink struct {
+	logger log.Logger
+	tracer trace.Tracer
+}
+
+// NewLogSink sink writing of events to the log
+func NewLogSink(logger log.Logger, tracer trace.Tracer) Sink {
+	return &logSink{logger: logger, tracer: tracer}
+}
+
+func (sink *logSink) Send(ctx context.Context, event *domain.OutboxEvent) error {
+	_, span := sink.tracer.Start(ctx, "Send")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("sink", "log"))
+
+	sink.logger.Infof("outbox: %s", event.Payload)
+
+	return nil
+}
+
+type fileSink struct {
+	file   string
+	tracer trace.Tracer
+}
+
+// NewFileSink sink appending of events to the file as json lines, the file is reopened for every event,
+// so it can be rotated
+func NewFileSink(file string, tracer trace.Tracer) Sink {
+	return &fileSink{file: file, tracer: tracer}
+}
+
+func (sink *fileSink) Send(ctx context.Context, event *domain.OutboxEvent) error {
+	_, span := sink.tracer.Start(ctx, "Send")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("sink", "file"))
+
+	file, err := os.OpenFile(sink.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.WriteString(event.Payload + "\n"); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	// the cursor is moved after Send, so the line must be on the disk before it
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
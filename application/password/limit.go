@@ -0,0 +1,122 @@
+package password
+
+import (
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/google/uuid"
+	"sort"
+)
+
+// activePasswords active passwords of the login counted by limits, oldest first, reset tokens are not counted
+func activePasswords(passwords []*repository.Password) []*repository.Password {
+	var active []*repository.Password
+
+	for _, password := range passwords {
+		if !password.Disabled && domain.PasswordType(password.Type) != domain.ResetType {
+			active = append(active, password)
+		}
+	}
+
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].CreatedAt.Before(*active[j].CreatedAt)
+	})
+
+	return active
+}
+
+// replaceTemporary removing of stored passwords which must be changed from active when the model is a regular
+// password, a primary one which is not required to be changed itself, their uuids are returned for disabling
+func replaceTemporary(active *[]*repository.Password, model *repository.Password) []uuid.UUID {
+	if model.MustChange || domain.PasswordType(model.Type) != domain.PrimaryType {
+		return nil
+	}
+
+	var uuids []uuid.UUID
+	remaining := []*repository.Password{}
+
+	for _, password := range *active {
+		if password.MustChange && password.Uuid != uuid.Nil {
+			uuids = append(uuids, password.Uuid)
+		} else {
+			remaining = append(remaining, password)
+		}
+	}
+
+	*active = remaining
+
+	return uuids
+}
+
+// makeRoom checking of limits of active passwords of the login for adding of the model, the model is appended
+// to active, on config.PasswordEvictOverflow the oldest stored passwords are removed from active and their
+// uuids are returned for disabling, LimitExceededError is returned if the model cannot be added
+func (service *password) makeRoom(active *[]*repository.Password, model *repository.Password) ([]uuid.UUID, error) {
+	evicted := make([]bool, len(*active))
+
+	// excess count of passwords matched by filter over the limit after adding of the model
+	excess := func(limit int, filter func(*repository.Password) bool) int {
+		if limit <= 0 {
+			return 0
+		}
+
+		count := 1
+		for index, password := range *active {
+			if !evicted[index] && filter(password) {
+				count++
+			}
+		}
+
+		return count - limit
+	}
+
+	// evicting of count oldest stored passwords matched by filter, false if there are not enough of them
+	evict := func(count int, filter func(*repository.Password) bool) bool {
+		for index, password := range *active {
+			if count <= 0 {
+				break
+			}
+
+			// not stored passwords of the same batch cannot be evicted
+			if evicted[index] || password.Uuid == uuid.Nil || !filter(password) {
+				continue
+			}
+
+			evicted[index] = true
+			count--
+		}
+
+		return count <= 0
+	}
+
+	sameType := func(password *repository.Password) bool { return password.Type == model.Type }
+	all := func(*repository.Password) bool { return true }
+
+	typeExcess := excess(service.config.ActiveLimits[model.Type], sameType)
+	totalExcess := excess(service.config.ActiveLimit, all)
+
+	if typeExcess > 0 || totalExcess > 0 {
+		if service.config.ActiveOverflow != config.PasswordEvictOverflow {
+			return nil, LimitExceededError
+		}
+
+		if !evict(typeExcess, sameType) || !evict(excess(service.config.ActiveLimit, all), all) {
+			return nil, LimitExceededError
+		}
+	}
+
+	var uuids []uuid.UUID
+	remaining := []*repository.Password{}
+
+	for index, password := range *active {
+		if evicted[index] {
+			uuids = append(uuids, password.Uuid)
+		} else {
+			remaining = append(remaining, password)
+		}
+	}
+
+	*active = append(remaining, model)
+
+	return uuids, nil
+}
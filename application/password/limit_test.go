@@ -0,0 +1,154 @@
+package password
+
+import (
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/google/uuid"
+	"reflect"
+	"testing"
+	goTime "time"
+)
+
+// testActive stored active passwords of the types, the first is the oldest
+func testActive(types ...domain.PasswordType) []*repository.Password {
+	passwords := make([]*repository.Password, len(types))
+
+	for index, _type := range types {
+		createdAt := time.NowUTC().Add(goTime.Duration(index-len(types)) * goTime.Minute)
+		passwords[index] = &repository.Password{Uuid: uuid.New(), Type: string(_type), CreatedAt: &createdAt}
+	}
+
+	return passwords
+}
+
+func TestActivePasswords(t *testing.T) {
+	passwords := testActive(domain.PrimaryType, domain.ResetType, domain.AppType, domain.PrimaryType)
+	passwords[3].Disabled = true
+
+	// the order of storing differs from the order of creation
+	passwords[0], passwords[2] = passwords[2], passwords[0]
+
+	active := activePasswords(passwords)
+
+	want := []*repository.Password{passwords[2], passwords[0]}
+	if !reflect.DeepEqual(active, want) {
+		t.Errorf("active %v, want %v", active, want)
+	}
+}
+
+func TestMakeRoom(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		limits   map[string]int
+		overflow string
+		active   []domain.PasswordType
+		batch    int
+		model    domain.PasswordType
+		evicted  []int
+		err      error
+	}{
+		{
+			name:   "unlimited",
+			active: []domain.PasswordType{domain.PrimaryType, domain.PrimaryType},
+			model:  domain.PrimaryType,
+		},
+		{
+			name:   "under the limit",
+			limit:  3,
+			active: []domain.PasswordType{domain.PrimaryType, domain.AppType},
+			model:  domain.PrimaryType,
+		},
+		{
+			name:     "total limit is rejected",
+			limit:    2,
+			overflow: config.PasswordRejectOverflow,
+			active:   []domain.PasswordType{domain.PrimaryType, domain.AppType},
+			model:    domain.PrimaryType,
+			err:      LimitExceededError,
+		},
+		{
+			name:     "oldest password is evicted by the total limit",
+			limit:    2,
+			overflow: config.PasswordEvictOverflow,
+			active:   []domain.PasswordType{domain.AppType, domain.PrimaryType},
+			model:    domain.PrimaryType,
+			evicted:  []int{0},
+		},
+		{
+			name:     "oldest password of the type is evicted by the limit of the type",
+			limits:   map[string]int{string(domain.PrimaryType): 1},
+			overflow: config.PasswordEvictOverflow,
+			active:   []domain.PasswordType{domain.AppType, domain.PrimaryType},
+			model:    domain.PrimaryType,
+			evicted:  []int{1},
+		},
+		{
+			name:     "limit of another type is not applied",
+			limits:   map[string]int{string(domain.AppType): 1},
+			overflow: config.PasswordRejectOverflow,
+			active:   []domain.PasswordType{domain.AppType, domain.PrimaryType},
+			model:    domain.PrimaryType,
+		},
+		{
+			name:     "evicted by the type counts for the total limit",
+			limit:    2,
+			limits:   map[string]int{string(domain.PrimaryType): 1},
+			overflow: config.PasswordEvictOverflow,
+			active:   []domain.PasswordType{domain.AppType, domain.PrimaryType},
+			model:    domain.PrimaryType,
+			evicted:  []int{1},
+		},
+		{
+			name:     "passwords of the same batch are not evicted",
+			limit:    2,
+			overflow: config.PasswordEvictOverflow,
+			active:   []domain.PasswordType{domain.PrimaryType, domain.PrimaryType},
+			batch:    2,
+			model:    domain.PrimaryType,
+			err:      LimitExceededError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, _, _, _ := newTestService(&config.Password{
+				ActiveLimit:    test.limit,
+				ActiveLimits:   test.limits,
+				ActiveOverflow: test.overflow,
+			}, &testRepository{})
+
+			active := testActive(test.active...)
+			for _, password := range active[len(active)-test.batch:] {
+				password.Uuid = uuid.Nil
+			}
+
+			stored := append([]*repository.Password{}, active...)
+			model := &repository.Password{Type: string(test.model)}
+
+			uuids, err := service.makeRoom(&active, model)
+			if err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			var evicted []uuid.UUID
+			for _, index := range test.evicted {
+				evicted = append(evicted, stored[index].Uuid)
+			}
+
+			if !reflect.DeepEqual(uuids, evicted) {
+				t.Errorf("evicted %v, want %v", uuids, evicted)
+			}
+
+			if count := len(stored) - len(test.evicted) + 1; len(active) != count || active[count-1] != model {
+				t.Errorf("%d active passwords, want %d ending with the model", len(active), count)
+			}
+		})
+	}
+}
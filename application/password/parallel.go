@@ -0,0 +1,29 @@
+package password
+
+import "sync"
+
+// parallel calling handler for every index from 0 to count, no more than limit calls at the same time
+func parallel(count int, limit int, handler func(index int)) {
+	if limit <= 0 {
+		limit = 1
+	}
+
+	semaphore := make(chan struct{}, limit)
+	wg := &sync.WaitGroup{}
+
+	for index := 0; index < count; index++ {
+		semaphore <- struct{}{}
+		wg.Add(1)
+
+		go func(index int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			handler(index)
+		}(index)
+	}
+
+	wg.Wait()
+}
@@ -2,25 +2,62 @@ package password
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
+	"github.com/Diez37/passwords/application/audit"
 	"github.com/Diez37/passwords/application/blocker"
 	"github.com/Diez37/passwords/application/hash"
+	"github.com/Diez37/passwords/application/outbox"
 	"github.com/Diez37/passwords/domain"
 	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/metrics"
 	"github.com/Diez37/passwords/infrastructure/repository"
 	"github.com/Diez37/passwords/infrastructure/time"
 	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	"sort"
+	"strings"
+)
+
+const (
+	scopesSeparator = ","
+
+	// resetTokenSize count of random bytes in the reset token
+	resetTokenSize = 32
 )
 
 var (
-	AlreadyExistError = errors.New("password already exist")
+	AlreadyExistError  = errors.New("password already exist")
+	LimitExceededError = errors.New("limit of active passwords of the login is exceeded")
 )
 
 type Service interface {
+	// Add adding of the password, passwords of the login which must be changed are disabled
+	// when the added password is a primary one which does not require changing itself
 	Add(ctx context.Context, password *domain.Password) error
+
+	// Check checking of the password, uuid, must change and expired grace flags of the matched password
+	// are set to the password
 	Check(ctx context.Context, password *domain.Password) (bool, error)
+	Update(ctx context.Context, password *domain.Password) (*domain.Password, error)
+
+	// AddBatch adding of passwords with one insert, return error for every password or general error,
+	// added passwords get uuid, creation and expiration times of stored records like by Add
+	AddBatch(ctx context.Context, passwords ...*domain.Password) ([]error, error)
+
+	// CheckBatch checking of passwords in parallel, return result and error for every password
+	CheckBatch(ctx context.Context, passwords ...*domain.Password) ([]bool, []error)
+
+	// IssueReset issuing of the single-use reset token of the login, only hash of the token is stored,
+	// return the token and the stored record of it
+	IssueReset(ctx context.Context, login uuid.UUID) (string, *domain.Password, error)
+
+	// Reset redeeming of the reset token of password.Login, the password is added and all other passwords
+	// of the login are disabled at once, false if the token is unknown, expired or already redeemed
+	Reset(ctx context.Context, token string, password *domain.Password) (bool, error)
 }
 
 type password struct {
@@ -28,11 +65,32 @@ type password struct {
 	blocker    blocker.Blocker
 	hasher     hash.Hasher
 	repository repository.Repository
+	auditor    audit.Auditor
+	outbox     outbox.Outbox
 	tracer     trace.Tracer
+	metrics    *metrics.Metrics
 }
 
-func NewPassword(config *config.Password, hasher hash.Hasher, repository repository.Repository, tracer trace.Tracer, blocker blocker.Blocker) Service {
-	return &password{config: config, hasher: hasher, repository: repository, tracer: tracer, blocker: blocker}
+func NewPassword(
+	config *config.Password,
+	hasher hash.Hasher,
+	repository repository.Repository,
+	tracer trace.Tracer,
+	blocker blocker.Blocker,
+	auditor audit.Auditor,
+	outbox outbox.Outbox,
+	metrics *metrics.Metrics,
+) Service {
+	return &password{
+		config:     config,
+		hasher:     hasher,
+		repository: repository,
+		tracer:     tracer,
+		blocker:    blocker,
+		auditor:    auditor,
+		outbox:     outbox,
+		metrics:    metrics,
+	}
 }
 
 func (service *password) Add(ctx context.Context, password *domain.Password) error {
@@ -41,20 +99,288 @@ func (service *password) Add(ctx context.Context, password *domain.Password) err
 
 	span.SetAttributes(attribute.String("service", "password"))
 
+	err := service.add(ctx, password)
+
+	service.metrics.AddTotal.WithLabelValues(addOutcome(err)).Inc()
+
+	return err
+}
+
+func (service *password) add(ctx context.Context, password *domain.Password) error {
 	passwords, err := service.repository.FindByLogin(ctx, password.Login)
 	if err != nil && err != db.RecordNotFoundError {
 		return err
 	}
 
+	model, err := service.makeModel(ctx, password, passwords)
+	if err == AlreadyExistError {
+		service.auditor.Record(ctx, domain.PolicyRejectedAction, password.Login, uuid.Nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var disabled []uuid.UUID
+
+	// limits are checked against active passwords read under the lock of the login, so concurrent additions
+	// cannot exceed them, the password is inserted and others are disabled in the same transaction
+	err = service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		active, err := service.lockActive(ctx, model.Login)
+		if err != nil {
+			return err
+		}
+
+		replaced := replaceTemporary(&active, model)
+
+		evicted, err := service.makeRoom(&active, model)
+		if err != nil {
+			return err
+		}
+
+		if _, err := service.repository.Insert(ctx, model); err != nil {
+			return err
+		}
+
+		if err := service.outbox.Append(ctx, domain.AddAction, model.Login, model.Uuid); err != nil {
+			return err
+		}
+
+		disabled = append(replaced, evicted...)
+
+		return service.disable(ctx, model.Login, disabled...)
+	})
+	if err == LimitExceededError {
+		service.auditor.Record(ctx, domain.PolicyRejectedAction, password.Login, uuid.Nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	service.auditor.Record(ctx, domain.AddAction, model.Login, model.Uuid)
+
+	for _, uuid := range disabled {
+		service.auditor.Record(ctx, domain.DisabledAction, model.Login, uuid)
+	}
+
+	password.Uuid = model.Uuid
+	password.CreatedAt = model.CreatedAt
+	password.ValidUntil = model.ValidUntil
+
+	return nil
+}
+
+// countAdded counting of outcomes of the batch addition
+func (service *password) countAdded(errs []error) {
+	for _, err := range errs {
+		service.metrics.AddTotal.WithLabelValues(addOutcome(err)).Inc()
+	}
+}
+
+// addOutcome outcome of the addition for metrics
+func addOutcome(err error) string {
+	switch err {
+	case nil:
+		return metrics.OkOutcome
+	case AlreadyExistError:
+		return metrics.ExistsOutcome
+	case LimitExceededError:
+		return metrics.LimitOutcome
+	default:
+		return metrics.ErrorOutcome
+	}
+}
+
+// lockActive locking of passwords of the login until the end of the transaction of the ctx
+// and reading of its active passwords counted by limits
+func (service *password) lockActive(ctx context.Context, login uuid.UUID) ([]*repository.Password, error) {
+	if err := service.repository.LockLogin(ctx, login); err != nil {
+		return nil, err
+	}
+
+	passwords, err := service.repository.FindActiveByLogin(ctx, login)
+	if err != nil && err != db.RecordNotFoundError {
+		return nil, err
+	}
+
+	return activePasswords(passwords), nil
+}
+
+// disable disabling of active passwords of the login locked by lockActive and appending of their events
+// in the transaction of the ctx, audit entries are recorded by the caller after the commit
+func (service *password) disable(ctx context.Context, login uuid.UUID, uuids ...uuid.UUID) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+
+	if _, err := service.repository.DisableByUuids(ctx, uuids...); err != nil {
+		return err
+	}
+
+	for _, uuid := range uuids {
+		if err := service.outbox.Append(ctx, domain.DisabledAction, login, uuid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (service *password) AddBatch(ctx context.Context, passwords ...*domain.Password) ([]error, error) {
+	ctx, span := service.tracer.Start(ctx, "AddBatch")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("service", "password"),
+		attribute.Int("count", len(passwords)),
+	)
+
+	errs := make([]error, len(passwords))
+	existing := map[uuid.UUID][]*repository.Password{}
+	unique := map[string]bool{}
+
+	for index, password := range passwords {
+		key := password.Login.String() + password.Password
+		if unique[key] {
+			errs[index] = AlreadyExistError
+			continue
+		}
+
+		unique[key] = true
+
+		if _, ok := existing[password.Login]; ok {
+			continue
+		}
+
+		models, err := service.repository.FindByLogin(ctx, password.Login)
+		if err != nil && err != db.RecordNotFoundError {
+			return nil, err
+		}
+
+		existing[password.Login] = models
+	}
+
+	models := make([]*repository.Password, len(passwords))
+
+	parallel(len(passwords), service.config.BatchParallelism, func(index int) {
+		if errs[index] != nil {
+			return
+		}
+
+		models[index], errs[index] = service.makeModel(ctx, passwords[index], existing[passwords[index].Login])
+	})
+
+	logins := make([]uuid.UUID, 0, len(existing))
+	for login := range existing {
+		logins = append(logins, login)
+	}
+
+	// logins are locked in the same order by every batch, so concurrent batches do not deadlock
+	sort.Slice(logins, func(i, j int) bool { return logins[i].String() < logins[j].String() })
+
+	var rows []*repository.Password
+	disabled := map[uuid.UUID][]uuid.UUID{}
+
+	// limits are checked against active passwords read under locks of logins like on Add,
+	// all passwords are inserted and others are disabled in one transaction
+	err := service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		active := map[uuid.UUID][]*repository.Password{}
+		for _, login := range logins {
+			passwords, err := service.lockActive(ctx, login)
+			if err != nil {
+				return err
+			}
+
+			active[login] = passwords
+		}
+
+		for index, model := range models {
+			if model == nil {
+				continue
+			}
+
+			list := active[model.Login]
+			replaced := replaceTemporary(&list, model)
+
+			evicted, err := service.makeRoom(&list, model)
+			if err != nil {
+				errs[index] = err
+				continue
+			}
+
+			active[model.Login] = list
+			disabled[model.Login] = append(disabled[model.Login], append(replaced, evicted...)...)
+			rows = append(rows, model)
+		}
+
+		if len(rows) == 0 {
+			return nil
+		}
+
+		if _, err := service.repository.InsertMany(ctx, rows...); err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			if err := service.outbox.Append(ctx, domain.AddAction, row.Login, row.Uuid); err != nil {
+				return err
+			}
+		}
+
+		for login, uuids := range disabled {
+			if err := service.disable(ctx, login, uuids...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		service.metrics.AddTotal.WithLabelValues(metrics.ErrorOutcome).Add(float64(len(passwords)))
+		return nil, err
+	}
+
+	for index, err := range errs {
+		if err == AlreadyExistError || err == LimitExceededError {
+			service.auditor.Record(ctx, domain.PolicyRejectedAction, passwords[index].Login, uuid.Nil)
+		}
+	}
+
+	service.countAdded(errs)
+
+	for _, row := range rows {
+		service.auditor.Record(ctx, domain.AddAction, row.Login, row.Uuid)
+	}
+
+	for login, uuids := range disabled {
+		for _, uuid := range uuids {
+			service.auditor.Record(ctx, domain.DisabledAction, login, uuid)
+		}
+	}
+
+	for index, model := range models {
+		if model != nil && errs[index] == nil {
+			passwords[index].Uuid = model.Uuid
+			passwords[index].CreatedAt = model.CreatedAt
+			passwords[index].ValidUntil = model.ValidUntil
+		}
+	}
+
+	return errs, nil
+}
+
+// makeModel checking that the password is not already exist in passwords of the login and hashing it
+func (service *password) makeModel(ctx context.Context, password *domain.Password, passwords []*repository.Password) (*repository.Password, error) {
 	for _, pas := range passwords {
 		if service.hasher.Check(ctx, password.Login, password.Password, pas.Password) {
-			return AlreadyExistError
+			return nil, AlreadyExistError
 		}
 	}
 
 	passwordHash, err := service.hasher.Password(ctx, password.Login, password.Password)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	ValidUntil := time.NowUTC().Add(service.config.Lifetime)
@@ -62,14 +388,23 @@ func (service *password) Add(ctx context.Context, password *domain.Password) err
 		ValidUntil = *password.ValidUntil
 	}
 
-	_, err = service.repository.Insert(ctx, &repository.Password{
+	passwordType := password.Type
+	if passwordType == "" {
+		passwordType = domain.PrimaryType
+	}
+
+	return &repository.Password{
 		Login:      password.Login,
 		Password:   passwordHash,
 		OneTime:    password.OneTime,
 		ValidUntil: &ValidUntil,
-	})
-
-	return err
+		Type:       string(passwordType),
+		Scopes:     strings.Join(password.Scopes, scopesSeparator),
+		Label:      password.Label,
+		MaxUses:    password.MaxUses,
+		ValidFrom:  password.ValidFrom,
+		MustChange: password.MustChange,
+	}, nil
 }
 
 func (service *password) Check(ctx context.Context, password *domain.Password) (bool, error) {
@@ -80,23 +415,330 @@ func (service *password) Check(ctx context.Context, password *domain.Password) (
 
 	passwords, err := service.repository.FindActiveByLogin(ctx, password.Login)
 	if err != nil && err != db.RecordNotFoundError {
+		service.metrics.CheckTotal.WithLabelValues(metrics.ErrorOutcome).Inc()
 		return false, err
 	}
 
 	for _, pas := range passwords {
+		if domain.PasswordType(pas.Type) == domain.ResetType {
+			continue
+		}
+
+		if !allowScopes(pas.Scopes, password.Scopes) {
+			continue
+		}
+
+		// the password is not active yet, it must not be blocked
+		if pas.ValidFrom != nil && pas.ValidFrom.After(time.NowUTC()) {
+			continue
+		}
+
 		if service.hasher.Check(ctx, password.Login, password.Password, pas.Password) {
-			if pas.ValidUntil.Sub(time.NowUTC()).Seconds() <= 0 {
+			expired := pas.ValidUntil.Sub(time.NowUTC()).Seconds() <= 0
+			if expired && pas.ValidUntil.Add(service.config.GracePeriod).Sub(time.NowUTC()).Seconds() <= 0 {
+				service.auditor.Record(ctx, domain.ExpiredAction, pas.Login, pas.Uuid)
+				service.metrics.CheckTotal.WithLabelValues(metrics.ExpiredOutcome).Inc()
 				service.blocker.Add(ctx, pas.Uuid)
 				return false, nil
 			}
 
-			if pas.OneTime {
-				service.blocker.Add(ctx, pas.Uuid)
+			password.Uuid = pas.Uuid
+			password.MustChange = pas.MustChange
+			password.ExpiredGrace = expired
+
+			// the password which must be changed is accepted once, the next check requires the new password
+			consumed := pas.OneTime || pas.MustChange || (pas.MaxUses > 0 && pas.UseCount+1 >= pas.MaxUses)
+
+			ok := false
+
+			err := service.outbox.Transaction(ctx, func(ctx context.Context) error {
+				var err error
+
+				// a concurrent check may have used up the password already
+				if ok, err = service.repository.Use(ctx, pas.Uuid); err != nil || !ok {
+					return err
+				}
+
+				if err := service.outbox.Append(ctx, domain.CheckSuccessAction, pas.Login, pas.Uuid); err != nil {
+					return err
+				}
+
+				if consumed {
+					return service.outbox.Append(ctx, domain.ConsumedAction, pas.Login, pas.Uuid)
+				}
+
+				return nil
+			})
+			if err != nil {
+				service.metrics.CheckTotal.WithLabelValues(metrics.ErrorOutcome).Inc()
+				return false, err
+			}
+
+			if !ok {
+				service.auditor.Record(ctx, domain.CheckFailureAction, pas.Login, pas.Uuid)
+				service.metrics.CheckTotal.WithLabelValues(metrics.LockedOutcome).Inc()
+				return false, nil
+			}
+
+			service.auditor.Record(ctx, domain.CheckSuccessAction, pas.Login, pas.Uuid)
+			service.metrics.CheckTotal.WithLabelValues(metrics.OkOutcome).Inc()
+
+			if consumed {
+				service.auditor.Record(ctx, domain.ConsumedAction, pas.Login, pas.Uuid)
 			}
 
 			return true, nil
 		}
 	}
 
+	service.auditor.Record(ctx, domain.CheckFailureAction, password.Login, uuid.Nil)
+	service.metrics.CheckTotal.WithLabelValues(metrics.WrongOutcome).Inc()
+
+	return false, nil
+}
+
+func (service *password) CheckBatch(ctx context.Context, passwords ...*domain.Password) ([]bool, []error) {
+	ctx, span := service.tracer.Start(ctx, "CheckBatch")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("service", "password"),
+		attribute.Int("count", len(passwords)),
+	)
+
+	results := make([]bool, len(passwords))
+	errs := make([]error, len(passwords))
+
+	parallel(len(passwords), service.config.BatchParallelism, func(index int) {
+		results[index], errs[index] = service.Check(ctx, passwords[index])
+	})
+
+	return results, errs
+}
+
+func (service *password) IssueReset(ctx context.Context, login uuid.UUID) (string, *domain.Password, error) {
+	ctx, span := service.tracer.Start(ctx, "IssueReset")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "password"))
+
+	random := make([]byte, resetTokenSize)
+	if _, err := rand.Read(random); err != nil {
+		return "", nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(random)
+
+	tokenHash, err := service.hasher.Password(ctx, login, token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	validUntil := time.NowUTC().Add(service.config.ResetLifetime)
+
+	model := &repository.Password{
+		Login:      login,
+		Password:   tokenHash,
+		OneTime:    true,
+		ValidUntil: &validUntil,
+		Type:       string(domain.ResetType),
+	}
+
+	err = service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := service.repository.Insert(ctx, model); err != nil {
+			return err
+		}
+
+		return service.outbox.Append(ctx, domain.AddAction, model.Login, model.Uuid)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	service.auditor.Record(ctx, domain.AddAction, model.Login, model.Uuid)
+
+	return token, ToDomain(model), nil
+}
+
+func (service *password) Reset(ctx context.Context, token string, password *domain.Password) (bool, error) {
+	ctx, span := service.tracer.Start(ctx, "Reset")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "password"))
+
+	passwords, err := service.repository.FindActiveByLogin(ctx, password.Login)
+	if err != nil && err != db.RecordNotFoundError {
+		return false, err
+	}
+
+	for _, pas := range passwords {
+		if domain.PasswordType(pas.Type) != domain.ResetType || !service.hasher.Check(ctx, password.Login, token, pas.Password) {
+			continue
+		}
+
+		if pas.ValidUntil.Sub(time.NowUTC()).Seconds() <= 0 {
+			service.auditor.Record(ctx, domain.ExpiredAction, pas.Login, pas.Uuid)
+			service.blocker.Add(ctx, pas.Uuid)
+			return false, nil
+		}
+
+		all, err := service.repository.FindByLogin(ctx, password.Login)
+		if err != nil && err != db.RecordNotFoundError {
+			return false, err
+		}
+
+		model, err := service.makeModel(ctx, password, all)
+		if err == AlreadyExistError {
+			service.auditor.Record(ctx, domain.PolicyRejectedAction, password.Login, uuid.Nil)
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		// the redeeming disables every active password of the login, limits are checked like on adding
+		// after the disabling, so nothing is left to evict
+		if _, err := service.makeRoom(&[]*repository.Password{}, model); err != nil {
+			if err == LimitExceededError {
+				service.auditor.Record(ctx, domain.PolicyRejectedAction, password.Login, uuid.Nil)
+			}
+
+			return false, err
+		}
+
+		err = service.outbox.Transaction(ctx, func(ctx context.Context) error {
+			var err error
+			if model, err = service.repository.Redeem(ctx, pas.Uuid, model); err != nil {
+				return err
+			}
+
+			if err := service.outbox.Append(ctx, domain.ConsumedAction, pas.Login, pas.Uuid); err != nil {
+				return err
+			}
+
+			for _, disabled := range passwords {
+				if err := service.outbox.Append(ctx, domain.DisabledAction, disabled.Login, disabled.Uuid); err != nil {
+					return err
+				}
+			}
+
+			return service.outbox.Append(ctx, domain.AddAction, model.Login, model.Uuid)
+		})
+		if err == db.RecordNotFoundError {
+			return false, nil
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		service.auditor.Record(ctx, domain.ConsumedAction, pas.Login, pas.Uuid)
+
+		for _, disabled := range passwords {
+			service.auditor.Record(ctx, domain.DisabledAction, disabled.Login, disabled.Uuid)
+		}
+
+		service.auditor.Record(ctx, domain.AddAction, model.Login, model.Uuid)
+
+		password.Uuid = model.Uuid
+		password.CreatedAt = model.CreatedAt
+		password.ValidUntil = model.ValidUntil
+
+		return true, nil
+	}
+
 	return false, nil
 }
+
+func (service *password) Update(ctx context.Context, password *domain.Password) (*domain.Password, error) {
+	ctx, span := service.tracer.Start(ctx, "Update")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "password"))
+
+	ValidUntil := time.NowUTC().Add(service.config.Lifetime)
+	if password.ValidUntil != nil {
+		ValidUntil = *password.ValidUntil
+	}
+
+	var model *repository.Password
+
+	err := service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		model, err = service.repository.Update(ctx, &repository.Password{
+			Uuid:       password.Uuid,
+			Disabled:   password.Disabled,
+			OneTime:    password.OneTime,
+			UpdateAt:   password.UpdateAt,
+			ValidUntil: &ValidUntil,
+		})
+		if err != nil || !model.Disabled {
+			return err
+		}
+
+		return service.outbox.Append(ctx, domain.DisabledAction, model.Login, model.Uuid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if model.Disabled {
+		service.auditor.Record(ctx, domain.DisabledAction, model.Login, model.Uuid)
+	}
+
+	return ToDomain(model), nil
+}
+
+// ToDomain converting of the stored password to domain.Password without the hash
+func ToDomain(model *repository.Password) *domain.Password {
+	return &domain.Password{
+		Uuid:       model.Uuid,
+		Login:      model.Login,
+		Disabled:   model.Disabled,
+		OneTime:    model.OneTime,
+		CreatedAt:  model.CreatedAt,
+		UpdateAt:   model.UpdateAt,
+		ValidUntil: model.ValidUntil,
+		Type:       domain.PasswordType(model.Type),
+		Scopes:     splitScopes(model.Scopes),
+		Label:      model.Label,
+		MaxUses:    model.MaxUses,
+		UseCount:   model.UseCount,
+		LastUsedAt: model.LastUsedAt,
+		ValidFrom:  model.ValidFrom,
+		MustChange: model.MustChange,
+	}
+}
+
+func splitScopes(scopes string) []string {
+	if scopes == "" {
+		return []string{}
+	}
+
+	return strings.Split(scopes, scopesSeparator)
+}
+
+// allowScopes checking that the password with stored scopes can be used in all required scopes
+func allowScopes(stored string, required []string) bool {
+	if stored == "" {
+		return true
+	}
+
+	allowed := map[string]bool{}
+	for _, scope := range splitScopes(stored) {
+		allowed[scope] = true
+	}
+
+	if len(required) == 0 {
+		return false
+	}
+
+	for _, scope := range required {
+		if !allowed[scope] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,921 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/blocker"
+	"github.com/Diez37/passwords/application/outbox"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/trace"
+	"reflect"
+	"testing"
+	goTime "time"
+)
+
+const testToken = "reset token"
+
+// testHasher hashes are the passwords with a prefix
+type testHasher struct{}
+
+func (testHasher) Password(_ context.Context, _ uuid.UUID, password string) (string, error) {
+	return "hash:" + password, nil
+}
+
+func (testHasher) Check(_ context.Context, _ uuid.UUID, password string, hash string) bool {
+	return hash == "hash:"+password
+}
+
+// testRepository in-memory passwords of logins, concurrent passwords are stored on locking of their login
+// like by additions committed while the service was waiting for the lock
+type testRepository struct {
+	repository.Repository
+
+	passwords  []*repository.Password
+	concurrent []*repository.Password
+	redeemErr  error
+}
+
+func (fake *testRepository) FindByLogin(_ context.Context, login uuid.UUID) ([]*repository.Password, error) {
+	var passwords []*repository.Password
+	for _, password := range fake.passwords {
+		if password.Login == login {
+			passwords = append(passwords, password)
+		}
+	}
+
+	if len(passwords) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return passwords, nil
+}
+
+func (fake *testRepository) FindActiveByLogin(ctx context.Context, login uuid.UUID) ([]*repository.Password, error) {
+	var active []*repository.Password
+	for _, password := range fake.passwords {
+		if password.Login == login && !password.Disabled {
+			active = append(active, password)
+		}
+	}
+
+	if len(active) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return active, nil
+}
+
+func (fake *testRepository) Redeem(_ context.Context, _ uuid.UUID, password *repository.Password) (*repository.Password, error) {
+	if fake.redeemErr != nil {
+		return nil, fake.redeemErr
+	}
+
+	for _, stored := range fake.passwords {
+		stored.Disabled = true
+	}
+
+	now := time.NowUTC()
+	password.Uuid = uuid.New()
+	password.CreatedAt = &now
+	fake.passwords = append(fake.passwords, password)
+
+	return password, nil
+}
+
+func (fake *testRepository) Insert(_ context.Context, password *repository.Password) (*repository.Password, error) {
+	now := time.NowUTC()
+	password.Uuid = uuid.New()
+	password.CreatedAt = &now
+	fake.passwords = append(fake.passwords, password)
+
+	return password, nil
+}
+
+func (fake *testRepository) InsertMany(ctx context.Context, passwords ...*repository.Password) ([]*repository.Password, error) {
+	for _, password := range passwords {
+		if _, err := fake.Insert(ctx, password); err != nil {
+			return nil, err
+		}
+	}
+
+	return passwords, nil
+}
+
+func (fake *testRepository) Update(_ context.Context, password *repository.Password) (*repository.Password, error) {
+	for _, stored := range fake.passwords {
+		if stored.Uuid == password.Uuid {
+			stored.Disabled = password.Disabled
+			stored.OneTime = password.OneTime
+			stored.ValidUntil = password.ValidUntil
+
+			return stored, nil
+		}
+	}
+
+	return nil, db.RecordNotFoundError
+}
+
+func (fake *testRepository) LockLogin(_ context.Context, login uuid.UUID) error {
+	var concurrent []*repository.Password
+	for _, password := range fake.concurrent {
+		if password.Login == login {
+			fake.passwords = append(fake.passwords, password)
+		} else {
+			concurrent = append(concurrent, password)
+		}
+	}
+
+	fake.concurrent = concurrent
+
+	return nil
+}
+
+func (fake *testRepository) Use(_ context.Context, uuid uuid.UUID) (bool, error) {
+	for _, stored := range fake.passwords {
+		if stored.Uuid == uuid && !stored.Disabled && (stored.MaxUses == 0 || stored.UseCount < stored.MaxUses) {
+			stored.UseCount++
+			stored.Disabled = stored.OneTime || stored.MustChange || (stored.MaxUses > 0 && stored.UseCount >= stored.MaxUses)
+
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (fake *testRepository) DisableByUuids(_ context.Context, uuids ...uuid.UUID) (bool, error) {
+	for _, stored := range fake.passwords {
+		for _, uuid := range uuids {
+			if stored.Uuid == uuid {
+				stored.Disabled = true
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// testAuditor recorded actions of the audit log
+type testAuditor struct {
+	audit.Auditor
+
+	actions []domain.AuditAction
+}
+
+func (fake *testAuditor) Record(_ context.Context, action domain.AuditAction, _ uuid.UUID, _ uuid.UUID) {
+	fake.actions = append(fake.actions, action)
+}
+
+// testOutbox appended actions, actions of failed transactions are dropped
+type testOutbox struct {
+	outbox.Outbox
+
+	actions []domain.AuditAction
+}
+
+func (fake *testOutbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	actions := fake.actions
+
+	if err := fn(ctx); err != nil {
+		fake.actions = actions
+		return err
+	}
+
+	return nil
+}
+
+func (fake *testOutbox) Append(_ context.Context, action domain.AuditAction, _ uuid.UUID, _ uuid.UUID) error {
+	fake.actions = append(fake.actions, action)
+	return nil
+}
+
+type testBlocker struct {
+	blocker.Blocker
+
+	uuids []uuid.UUID
+}
+
+func (fake *testBlocker) Add(_ context.Context, uuid uuid.UUID) {
+	fake.uuids = append(fake.uuids, uuid)
+}
+
+func newTestService(passwordConfig *config.Password, repository *testRepository) (*password, *testAuditor, *testOutbox, *testBlocker) {
+	auditor, outbox, blocker := &testAuditor{}, &testOutbox{}, &testBlocker{}
+
+	service := NewPassword(
+		passwordConfig,
+		testHasher{},
+		repository,
+		trace.NewNoopTracerProvider().Tracer(""),
+		blocker,
+		auditor,
+		outbox,
+		&metrics.Metrics{
+			CheckTotal: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "checks"}, []string{"outcome"}),
+			AddTotal:   prometheus.NewCounterVec(prometheus.CounterOpts{Name: "adds"}, []string{"outcome"}),
+		},
+	).(*password)
+
+	return service, auditor, outbox, blocker
+}
+
+func testPasswords(login uuid.UUID, tokenValidity goTime.Duration) []*repository.Password {
+	createdAt := time.NowUTC().Add(-goTime.Hour)
+	primaryValidUntil := time.NowUTC().Add(goTime.Hour)
+	tokenValidUntil := time.NowUTC().Add(tokenValidity)
+
+	return []*repository.Password{
+		{
+			Uuid:       uuid.New(),
+			Login:      login,
+			Password:   "hash:old password",
+			ValidUntil: &primaryValidUntil,
+			CreatedAt:  &createdAt,
+			Type:       string(domain.PrimaryType),
+		},
+		{
+			Uuid:       uuid.New(),
+			Login:      login,
+			Password:   "hash:" + testToken,
+			OneTime:    true,
+			ValidUntil: &tokenValidUntil,
+			CreatedAt:  &createdAt,
+			Type:       string(domain.ResetType),
+		},
+	}
+}
+
+func TestServiceReset(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		password      string
+		tokenValidity goTime.Duration
+		redeemErr     error
+		ok            bool
+		err           error
+		audit         []domain.AuditAction
+		events        []domain.AuditAction
+		blocked       int
+	}{
+		{
+			name:          "redeemed token",
+			token:         testToken,
+			password:      "new password",
+			tokenValidity: goTime.Hour,
+			ok:            true,
+			audit:         []domain.AuditAction{domain.ConsumedAction, domain.DisabledAction, domain.DisabledAction, domain.AddAction},
+			events:        []domain.AuditAction{domain.ConsumedAction, domain.DisabledAction, domain.DisabledAction, domain.AddAction},
+		},
+		{
+			name:          "wrong token",
+			token:         "wrong token",
+			password:      "new password",
+			tokenValidity: goTime.Hour,
+		},
+		{
+			name:          "expired token",
+			token:         testToken,
+			password:      "new password",
+			tokenValidity: -goTime.Hour,
+			audit:         []domain.AuditAction{domain.ExpiredAction},
+			blocked:       1,
+		},
+		{
+			name:          "reused password",
+			token:         testToken,
+			password:      "old password",
+			tokenValidity: goTime.Hour,
+			err:           AlreadyExistError,
+			audit:         []domain.AuditAction{domain.PolicyRejectedAction},
+		},
+		{
+			name:          "token redeemed concurrently",
+			token:         testToken,
+			password:      "new password",
+			tokenValidity: goTime.Hour,
+			redeemErr:     db.RecordNotFoundError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			login := uuid.New()
+			repository := &testRepository{passwords: testPasswords(login, test.tokenValidity), redeemErr: test.redeemErr}
+			service, auditor, outbox, blocker := newTestService(&config.Password{Lifetime: goTime.Hour, ActiveLimit: 1}, repository)
+
+			ok, err := service.Reset(context.Background(), test.token, &domain.Password{Login: login, Password: test.password})
+			if err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if ok != test.ok {
+				t.Errorf("ok %t, want %t", ok, test.ok)
+			}
+
+			if !reflect.DeepEqual(auditor.actions, test.audit) {
+				t.Errorf("audit %v, want %v", auditor.actions, test.audit)
+			}
+
+			if !reflect.DeepEqual(outbox.actions, test.events) {
+				t.Errorf("events %v, want %v", outbox.actions, test.events)
+			}
+
+			if len(blocker.uuids) != test.blocked {
+				t.Errorf("%d blocked passwords, want %d", len(blocker.uuids), test.blocked)
+			}
+		})
+	}
+}
+
+func TestServiceIssueReset(t *testing.T) {
+	login := uuid.New()
+	repository := &testRepository{}
+	service, auditor, outbox, _ := newTestService(&config.Password{ResetLifetime: goTime.Hour}, repository)
+
+	token, password, err := service.IssueReset(context.Background(), login)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if password.Type != domain.ResetType || !password.OneTime {
+		t.Errorf("password of type %s and one-time %t, want one-time %s", password.Type, password.OneTime, domain.ResetType)
+	}
+
+	if !(testHasher{}).Check(context.Background(), login, token, repository.passwords[0].Password) {
+		t.Error("stored hash does not match the token")
+	}
+
+	want := []domain.AuditAction{domain.AddAction}
+
+	if !reflect.DeepEqual(auditor.actions, want) {
+		t.Errorf("audit %v, want %v", auditor.actions, want)
+	}
+
+	if !reflect.DeepEqual(outbox.actions, want) {
+		t.Errorf("events %v, want %v", outbox.actions, want)
+	}
+}
+
+func TestServiceUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		disabled bool
+		unknown  bool
+		err      error
+		actions  []domain.AuditAction
+	}{
+		{name: "disabled password", disabled: true, actions: []domain.AuditAction{domain.DisabledAction}},
+		{name: "prolonged password"},
+		{name: "unknown password", unknown: true, err: db.RecordNotFoundError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			login := uuid.New()
+			repository := &testRepository{passwords: testPasswords(login, goTime.Hour)}
+			service, auditor, outbox, _ := newTestService(&config.Password{Lifetime: goTime.Hour}, repository)
+
+			password := &domain.Password{Uuid: repository.passwords[0].Uuid, Disabled: test.disabled}
+			if test.unknown {
+				password.Uuid = uuid.New()
+			}
+
+			if _, err := service.Update(context.Background(), password); err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if !reflect.DeepEqual(auditor.actions, test.actions) {
+				t.Errorf("audit %v, want %v", auditor.actions, test.actions)
+			}
+
+			if !reflect.DeepEqual(outbox.actions, test.actions) {
+				t.Errorf("events %v, want %v", outbox.actions, test.actions)
+			}
+		})
+	}
+}
+
+// testStored active primary password of the login with the hash of the password "secret"
+func testStored(login uuid.UUID) *repository.Password {
+	createdAt := time.NowUTC().Add(-goTime.Hour)
+	validUntil := time.NowUTC().Add(goTime.Hour)
+
+	return &repository.Password{
+		Uuid:       uuid.New(),
+		Login:      login,
+		Password:   "hash:secret",
+		ValidUntil: &validUntil,
+		CreatedAt:  &createdAt,
+		Type:       string(domain.PrimaryType),
+	}
+}
+
+// testExpired change of the stored password expired the duration ago
+func testExpired(ago goTime.Duration) func(stored *repository.Password) {
+	return func(stored *repository.Password) {
+		validUntil := time.NowUTC().Add(-ago)
+		stored.ValidUntil = &validUntil
+	}
+}
+
+func TestServiceCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(stored *repository.Password)
+		password string
+		scopes   []string
+		ok       bool
+		consumed bool
+		blocked  bool
+		must     bool
+		grace    goTime.Duration
+		expired  bool
+		audit    []domain.AuditAction
+	}{
+		{
+			name:     "valid password",
+			change:   func(*repository.Password) {},
+			password: "secret",
+			ok:       true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction},
+		},
+		{
+			name:     "wrong password",
+			change:   func(*repository.Password) {},
+			password: "wrong",
+			audit:    []domain.AuditAction{domain.CheckFailureAction},
+		},
+		{
+			name:     "reset token is not a password",
+			change:   func(stored *repository.Password) { stored.Type = string(domain.ResetType) },
+			password: "secret",
+			audit:    []domain.AuditAction{domain.CheckFailureAction},
+		},
+		{
+			name:     "password without scopes in any scope",
+			change:   func(*repository.Password) {},
+			password: "secret",
+			scopes:   []string{"ssh"},
+			ok:       true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction},
+		},
+		{
+			name:     "password in its scopes",
+			change:   func(stored *repository.Password) { stored.Scopes = "ssh,vpn" },
+			password: "secret",
+			scopes:   []string{"vpn", "ssh"},
+			ok:       true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction},
+		},
+		{
+			name:     "password out of its scopes",
+			change:   func(stored *repository.Password) { stored.Scopes = "ssh" },
+			password: "secret",
+			scopes:   []string{"ssh", "web"},
+			audit:    []domain.AuditAction{domain.CheckFailureAction},
+		},
+		{
+			name:     "scoped password without required scopes",
+			change:   func(stored *repository.Password) { stored.Scopes = "ssh" },
+			password: "secret",
+			audit:    []domain.AuditAction{domain.CheckFailureAction},
+		},
+		{
+			name:     "one-time password is consumed",
+			change:   func(stored *repository.Password) { stored.OneTime = true },
+			password: "secret",
+			ok:       true,
+			consumed: true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction, domain.ConsumedAction},
+		},
+		{
+			name: "password under the limit of uses",
+			change: func(stored *repository.Password) {
+				stored.MaxUses = 3
+				stored.UseCount = 1
+			},
+			password: "secret",
+			ok:       true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction},
+		},
+		{
+			name: "last use of the password",
+			change: func(stored *repository.Password) {
+				stored.MaxUses = 3
+				stored.UseCount = 2
+			},
+			password: "secret",
+			ok:       true,
+			consumed: true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction, domain.ConsumedAction},
+		},
+		{
+			name: "password used up concurrently",
+			change: func(stored *repository.Password) {
+				stored.MaxUses = 1
+				stored.UseCount = 1
+			},
+			password: "secret",
+			audit:    []domain.AuditAction{domain.CheckFailureAction},
+		},
+		{
+			name: "activated password",
+			change: func(stored *repository.Password) {
+				validFrom := time.NowUTC().Add(-goTime.Minute)
+				stored.ValidFrom = &validFrom
+			},
+			password: "secret",
+			ok:       true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction},
+		},
+		{
+			name: "not yet active password is not blocked",
+			change: func(stored *repository.Password) {
+				validFrom := time.NowUTC().Add(goTime.Minute)
+				stored.ValidFrom = &validFrom
+			},
+			password: "secret",
+			audit:    []domain.AuditAction{domain.CheckFailureAction},
+		},
+		{
+			name:     "temporary password must be changed",
+			change:   func(stored *repository.Password) { stored.MustChange = true },
+			password: "secret",
+			ok:       true,
+			consumed: true,
+			must:     true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction, domain.ConsumedAction},
+		},
+		{
+			name:     "expired password is blocked",
+			change:   testExpired(goTime.Minute),
+			password: "secret",
+			blocked:  true,
+			audit:    []domain.AuditAction{domain.ExpiredAction},
+		},
+		{
+			name:     "expired password in the grace period",
+			change:   testExpired(goTime.Minute),
+			password: "secret",
+			grace:    goTime.Hour,
+			ok:       true,
+			expired:  true,
+			audit:    []domain.AuditAction{domain.CheckSuccessAction},
+		},
+		{
+			name:     "expired password after the grace period",
+			change:   testExpired(2 * goTime.Hour),
+			password: "secret",
+			grace:    goTime.Hour,
+			blocked:  true,
+			audit:    []domain.AuditAction{domain.ExpiredAction},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			login := uuid.New()
+			stored := testStored(login)
+			test.change(stored)
+
+			service, auditor, outbox, blocker := newTestService(
+				&config.Password{GracePeriod: test.grace},
+				&testRepository{passwords: []*repository.Password{stored}},
+			)
+			password := &domain.Password{Login: login, Password: test.password, Scopes: test.scopes}
+
+			ok, err := service.Check(context.Background(), password)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != test.ok {
+				t.Errorf("ok %t, want %t", ok, test.ok)
+			}
+
+			if ok && password.Uuid != stored.Uuid {
+				t.Errorf("uuid %s, want %s", password.Uuid, stored.Uuid)
+			}
+
+			if password.MustChange != test.must {
+				t.Errorf("must change %t, want %t", password.MustChange, test.must)
+			}
+
+			if password.ExpiredGrace != test.expired {
+				t.Errorf("expired in grace %t, want %t", password.ExpiredGrace, test.expired)
+			}
+
+			if stored.Disabled != test.consumed {
+				t.Errorf("consumed %t, want %t", stored.Disabled, test.consumed)
+			}
+
+			if blocked := len(blocker.uuids) > 0; blocked != test.blocked {
+				t.Errorf("blocked %t, want %t", blocked, test.blocked)
+			}
+
+			if !reflect.DeepEqual(auditor.actions, test.audit) {
+				t.Errorf("audit %v, want %v", auditor.actions, test.audit)
+			}
+
+			// failed checks are only audited
+			var events []domain.AuditAction
+			for _, action := range test.audit {
+				if action != domain.CheckFailureAction && action != domain.ExpiredAction {
+					events = append(events, action)
+				}
+			}
+
+			if !reflect.DeepEqual(outbox.actions, events) {
+				t.Errorf("events %v, want %v", outbox.actions, events)
+			}
+		})
+	}
+}
+
+func TestServiceAdd(t *testing.T) {
+	tests := []struct {
+		name         string
+		must         bool
+		passwordType domain.PasswordType
+		other        bool
+		disabled     bool
+		audit        []domain.AuditAction
+	}{
+		{
+			name:     "permanent password replaces the temporary one",
+			disabled: true,
+			audit:    []domain.AuditAction{domain.AddAction, domain.DisabledAction},
+		},
+		{
+			name:  "temporary password keeps the temporary one",
+			must:  true,
+			audit: []domain.AuditAction{domain.AddAction},
+		},
+		{
+			name:         "app password keeps the temporary one",
+			passwordType: domain.AppType,
+			audit:        []domain.AuditAction{domain.AddAction},
+		},
+		{
+			name:  "password of another login keeps the temporary one",
+			other: true,
+			audit: []domain.AuditAction{domain.AddAction},
+		},
+	}
+
+	for _, test := range tests {
+		for _, batch := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s, batch %t", test.name, batch), func(t *testing.T) {
+				login := uuid.New()
+				temporary := testStored(login)
+				temporary.MustChange = true
+
+				repository := &testRepository{passwords: []*repository.Password{temporary}}
+				service, auditor, outbox, _ := newTestService(&config.Password{Lifetime: goTime.Hour}, repository)
+
+				password := &domain.Password{Login: login, Password: "new secret", MustChange: test.must, Type: test.passwordType}
+				if test.other {
+					password.Login = uuid.New()
+				}
+
+				if batch {
+					if errs, err := service.AddBatch(context.Background(), password); err != nil || errs[0] != nil {
+						t.Fatalf("errors %v and %v", errs, err)
+					}
+				} else if err := service.Add(context.Background(), password); err != nil {
+					t.Fatal(err)
+				}
+
+				if password.Uuid == uuid.Nil || len(repository.passwords) != 2 {
+					t.Errorf("password is not added")
+				}
+
+				if temporary.Disabled != test.disabled {
+					t.Errorf("temporary password disabled %t, want %t", temporary.Disabled, test.disabled)
+				}
+
+				if !reflect.DeepEqual(auditor.actions, test.audit) {
+					t.Errorf("audit %v, want %v", auditor.actions, test.audit)
+				}
+
+				if !reflect.DeepEqual(outbox.actions, test.audit) {
+					t.Errorf("events %v, want %v", outbox.actions, test.audit)
+				}
+			})
+		}
+	}
+}
+
+func TestServiceAddConcurrent(t *testing.T) {
+	tests := []struct {
+		name     string
+		overflow string
+		err      error
+		audit    []domain.AuditAction
+	}{
+		{
+			name:     "limit is checked on passwords read under the lock",
+			overflow: config.PasswordRejectOverflow,
+			err:      LimitExceededError,
+			audit:    []domain.AuditAction{domain.PolicyRejectedAction},
+		},
+		{
+			name:     "concurrently added password is evicted",
+			overflow: config.PasswordEvictOverflow,
+			audit:    []domain.AuditAction{domain.AddAction, domain.DisabledAction},
+		},
+	}
+
+	for _, test := range tests {
+		for _, batch := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s, batch %t", test.name, batch), func(t *testing.T) {
+				login := uuid.New()
+				concurrent := testStored(login)
+
+				repository := &testRepository{concurrent: []*repository.Password{concurrent}}
+				service, auditor, outbox, _ := newTestService(
+					&config.Password{Lifetime: goTime.Hour, ActiveLimit: 1, ActiveOverflow: test.overflow},
+					repository,
+				)
+
+				password := &domain.Password{Login: login, Password: "new secret"}
+
+				var err error
+				if batch {
+					var errs []error
+					if errs, err = service.AddBatch(context.Background(), password); err == nil {
+						err = errs[0]
+					}
+				} else {
+					err = service.Add(context.Background(), password)
+				}
+
+				if err != test.err {
+					t.Fatalf("error %v, want %v", err, test.err)
+				}
+
+				if concurrent.Disabled != (test.err == nil) {
+					t.Errorf("concurrent password disabled %t, want %t", concurrent.Disabled, test.err == nil)
+				}
+
+				if !reflect.DeepEqual(auditor.actions, test.audit) {
+					t.Errorf("audit %v, want %v", auditor.actions, test.audit)
+				}
+
+				// the rejected addition leaves no events
+				var events []domain.AuditAction
+				if test.err == nil {
+					events = test.audit
+				}
+
+				if !reflect.DeepEqual(outbox.actions, events) {
+					t.Errorf("events %v, want %v", outbox.actions, events)
+				}
+			})
+		}
+	}
+}
+
+func TestServiceAddBatch(t *testing.T) {
+	login := uuid.New()
+	repository := &testRepository{passwords: []*repository.Password{testStored(login)}}
+	service, auditor, outbox, _ := newTestService(&config.Password{Lifetime: goTime.Hour, ActiveLimit: 3}, repository)
+
+	tests := []struct {
+		password string
+		err      error
+	}{
+		{password: "first"},
+		{password: "first", err: AlreadyExistError},
+		{password: "secret", err: AlreadyExistError},
+		{password: "second"},
+		{password: "third", err: LimitExceededError},
+	}
+
+	passwords := make([]*domain.Password, len(tests))
+	for index, test := range tests {
+		passwords[index] = &domain.Password{Login: login, Password: test.password}
+	}
+
+	errs, err := service.AddBatch(context.Background(), passwords...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for index, test := range tests {
+		if errs[index] != test.err {
+			t.Errorf("%d %s: error %v, want %v", index, test.password, errs[index], test.err)
+		}
+
+		if added := passwords[index].Uuid != uuid.Nil; added != (test.err == nil) {
+			t.Errorf("%d %s: uuid %s, want it only for added passwords", index, test.password, passwords[index].Uuid)
+		}
+	}
+
+	if len(repository.passwords) != 3 {
+		t.Errorf("%d stored passwords, want 3", len(repository.passwords))
+	}
+
+	audit := []domain.AuditAction{
+		domain.PolicyRejectedAction,
+		domain.PolicyRejectedAction,
+		domain.PolicyRejectedAction,
+		domain.AddAction,
+		domain.AddAction,
+	}
+
+	if !reflect.DeepEqual(auditor.actions, audit) {
+		t.Errorf("audit %v, want %v", auditor.actions, audit)
+	}
+
+	if events := []domain.AuditAction{domain.AddAction, domain.AddAction}; !reflect.DeepEqual(outbox.actions, events) {
+		t.Errorf("events %v, want %v", outbox.actions, events)
+	}
+}
+
+func TestServiceCheckBatch(t *testing.T) {
+	login := uuid.New()
+	service, _, _, _ := newTestService(
+		&config.Password{BatchParallelism: 1},
+		&testRepository{passwords: []*repository.Password{testStored(login)}},
+	)
+
+	tests := []struct {
+		password string
+		ok       bool
+	}{
+		{password: "secret", ok: true},
+		{password: "wrong"},
+		{password: "secret", ok: true},
+	}
+
+	passwords := make([]*domain.Password, len(tests))
+	for index, test := range tests {
+		passwords[index] = &domain.Password{Login: login, Password: test.password}
+	}
+
+	results, errs := service.CheckBatch(context.Background(), passwords...)
+
+	for index, test := range tests {
+		if errs[index] != nil {
+			t.Errorf("%d %s: error %v", index, test.password, errs[index])
+		}
+
+		if results[index] != test.ok {
+			t.Errorf("%d %s: ok %t, want %t", index, test.password, results[index], test.ok)
+		}
+	}
+}
+
+func TestServiceOutcomes(t *testing.T) {
+	login := uuid.New()
+	expired, used := testStored(login), testStored(login)
+	testExpired(goTime.Minute)(expired)
+	expired.Password = "hash:expired"
+	used.Password, used.MaxUses, used.UseCount = "hash:used", 1, 1
+
+	repository := &testRepository{passwords: []*repository.Password{testStored(login), expired, used}}
+	service, _, _, _ := newTestService(&config.Password{Lifetime: goTime.Hour, ActiveLimit: 4}, repository)
+	ctx := context.Background()
+
+	for _, password := range []string{"secret", "wrong", "expired", "used"} {
+		if _, err := service.Check(ctx, &domain.Password{Login: login, Password: password}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, password := range []string{"new", "secret", "newer"} {
+		_ = service.Add(ctx, &domain.Password{Login: login, Password: password})
+	}
+
+	tests := []struct {
+		name    string
+		counter *prometheus.CounterVec
+		outcome string
+		count   float64
+	}{
+		{name: "check", counter: service.metrics.CheckTotal, outcome: metrics.OkOutcome, count: 1},
+		{name: "check", counter: service.metrics.CheckTotal, outcome: metrics.WrongOutcome, count: 1},
+		{name: "check", counter: service.metrics.CheckTotal, outcome: metrics.ExpiredOutcome, count: 1},
+		{name: "check", counter: service.metrics.CheckTotal, outcome: metrics.LockedOutcome, count: 1},
+		{name: "add", counter: service.metrics.AddTotal, outcome: metrics.OkOutcome, count: 1},
+		{name: "add", counter: service.metrics.AddTotal, outcome: metrics.ExistsOutcome, count: 1},
+		{name: "add", counter: service.metrics.AddTotal, outcome: metrics.LimitOutcome, count: 1},
+	}
+
+	for _, test := range tests {
+		if count := testutil.ToFloat64(test.counter.WithLabelValues(test.outcome)); count != test.count {
+			t.Errorf("%s outcome %s counted %v times, want %v", test.name, test.outcome, count, test.count)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package recovery
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/generator"
+	"github.com/Diez37/passwords/application/outbox"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+const (
+	alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+var (
+	InvalidCountError = errors.New("invalid count of recovery codes")
+)
+
+type Service interface {
+	// Generate generation of new batch of codes for the login, the previous batch is revoked,
+	// codes are returned in plaintext only here
+	Generate(ctx context.Context, login uuid.UUID, count int) ([]string, error)
+
+	// Use checking of the code, the valid code is spent
+	Use(ctx context.Context, login uuid.UUID, code string) (bool, error)
+
+	// Remaining return count of not used codes of the login
+	Remaining(ctx context.Context, login uuid.UUID) (int64, error)
+
+	// Revoke disabling of all not used codes of the login, return count of disabled codes
+	Revoke(ctx context.Context, login uuid.UUID) (int64, error)
+}
+
+type recovery struct {
+	config     *config.Recovery
+	hashConfig *config.Hash
+	generator  generator.Generator
+	repository repository.RecoveryRepository
+	auditor    audit.Auditor
+	outbox     outbox.Outbox
+	tracer     trace.Tracer
+	metrics    *metrics.Metrics
+}
+
+func NewRecovery(
+	config *config.Recovery,
+	hashConfig *config.Hash,
+	generator generator.Generator,
+	repository repository.RecoveryRepository,
+	tracer trace.Tracer,
+	auditor audit.Auditor,
+	outbox outbox.Outbox,
+	metrics *metrics.Metrics,
+) Service {
+	return &recovery{
+		config:     config,
+		hashConfig: hashConfig,
+		generator:  generator,
+		repository: repository,
+		auditor:    auditor,
+		outbox:     outbox,
+		tracer:     tracer,
+		metrics:    metrics,
+	}
+}
+
+func (service *recovery) Generate(ctx context.Context, login uuid.UUID, count int) ([]string, error) {
+	ctx, span := service.tracer.Start(ctx, "Generate")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "recovery"))
+
+	if count == 0 {
+		count = service.config.Count
+	}
+
+	if count < 0 || count > config.RecoveryCountMaximum {
+		return nil, InvalidCountError
+	}
+
+	profile := &generator.Profile{Kind: generator.RandomKind, Length: service.config.Length, Alphabet: alphabet}
+
+	codes := make([]string, count)
+	models := make([]*repository.RecoveryCode, count)
+
+	for index := range codes {
+		code, err := service.generator.Generate(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+
+		codes[index] = code
+		models[index] = &repository.RecoveryCode{Hash: service.hash(login, code)}
+	}
+
+	err := service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		if _, err := service.repository.ReplaceRecoveryCodes(ctx, login, models...); err != nil {
+			return err
+		}
+
+		return service.outbox.Append(ctx, domain.RecoveryGeneratedAction, login, uuid.Nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	service.auditor.Record(ctx, domain.RecoveryGeneratedAction, login, uuid.Nil)
+
+	return codes, nil
+}
+
+func (service *recovery) Use(ctx context.Context, login uuid.UUID, code string) (bool, error) {
+	ctx, span := service.tracer.Start(ctx, "Use")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "recovery"))
+
+	hash := service.hash(login, code)
+	ok := false
+
+	err := service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		used, err := service.repository.UseRecoveryCode(ctx, login, hash)
+		if err != nil || !used {
+			return err
+		}
+
+		ok = true
+
+		return service.outbox.Append(ctx, domain.RecoveryUsedAction, login, uuid.Nil)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if ok {
+		service.auditor.Record(ctx, domain.RecoveryUsedAction, login, uuid.Nil)
+	}
+
+	return ok, nil
+}
+
+func (service *recovery) Remaining(ctx context.Context, login uuid.UUID) (int64, error) {
+	ctx, span := service.tracer.Start(ctx, "Remaining")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "recovery"))
+
+	return service.repository.CountRecoveryCodes(ctx, login)
+}
+
+func (service *recovery) Revoke(ctx context.Context, login uuid.UUID) (int64, error) {
+	ctx, span := service.tracer.Start(ctx, "Revoke")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "recovery"))
+
+	count := int64(0)
+
+	err := service.outbox.Transaction(ctx, func(ctx context.Context) error {
+		disabled, err := service.repository.DisableRecoveryCodes(ctx, login)
+		if err != nil || disabled == 0 {
+			return err
+		}
+
+		count = disabled
+
+		return service.outbox.Append(ctx, domain.RecoveryRevokedAction, login, uuid.Nil)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if count > 0 {
+		service.auditor.Record(ctx, domain.RecoveryRevokedAction, login, uuid.Nil)
+	}
+
+	return count, nil
+}
+
+// hash keyed hash of the normalized code, codes have enough entropy for a fast hash,
+// so a code is found by one indexed lookup instead of bcrypt comparison with every code
+func (service *recovery) hash(login uuid.UUID, code string) string {
+	defer service.metrics.Hash(metrics.HmacSha256Algorithm).ObserveDuration()
+
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+
+	mac := hmac.New(sha256.New, []byte(service.hashConfig.Salt))
+	mac.Write([]byte(login.String()))
+	mac.Write([]byte(code))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
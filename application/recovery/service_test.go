@@ -0,0 +1,252 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/generator"
+	"github.com/Diez37/passwords/application/outbox"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testGenerator sequential codes of the requested length
+type testGenerator struct {
+	count int
+}
+
+func (fake *testGenerator) Generate(_ context.Context, profile *generator.Profile) (string, error) {
+	fake.count++
+
+	code := fmt.Sprintf("%0*d", profile.Length, fake.count)
+
+	return strings.Map(func(digit rune) rune { return rune(profile.Alphabet[digit-'0']) }, code), nil
+}
+
+// testRepository in-memory active codes by logins, used and revoked codes are removed
+type testRepository struct {
+	codes map[uuid.UUID]map[string]bool
+}
+
+func (fake *testRepository) ReplaceRecoveryCodes(_ context.Context, login uuid.UUID, codes ...*repository.RecoveryCode) ([]*repository.RecoveryCode, error) {
+	fake.codes[login] = map[string]bool{}
+	for _, code := range codes {
+		fake.codes[login][code.Hash] = true
+	}
+
+	return codes, nil
+}
+
+func (fake *testRepository) UseRecoveryCode(_ context.Context, login uuid.UUID, hash string) (bool, error) {
+	if !fake.codes[login][hash] {
+		return false, nil
+	}
+
+	delete(fake.codes[login], hash)
+
+	return true, nil
+}
+
+func (fake *testRepository) CountRecoveryCodes(_ context.Context, login uuid.UUID) (int64, error) {
+	return int64(len(fake.codes[login])), nil
+}
+
+func (fake *testRepository) DisableRecoveryCodes(_ context.Context, login uuid.UUID) (int64, error) {
+	count := int64(len(fake.codes[login]))
+	delete(fake.codes, login)
+
+	return count, nil
+}
+
+// testAuditor recorded actions of the audit log
+type testAuditor struct {
+	audit.Auditor
+
+	actions []domain.AuditAction
+}
+
+func (fake *testAuditor) Record(_ context.Context, action domain.AuditAction, _ uuid.UUID, _ uuid.UUID) {
+	fake.actions = append(fake.actions, action)
+}
+
+// testOutbox appended actions, actions of failed transactions are dropped
+type testOutbox struct {
+	outbox.Outbox
+
+	actions []domain.AuditAction
+}
+
+func (fake *testOutbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	actions := fake.actions
+
+	if err := fn(ctx); err != nil {
+		fake.actions = actions
+		return err
+	}
+
+	return nil
+}
+
+func (fake *testOutbox) Append(_ context.Context, action domain.AuditAction, _ uuid.UUID, _ uuid.UUID) error {
+	fake.actions = append(fake.actions, action)
+	return nil
+}
+
+func newTestRecovery() (Service, *testRepository, *testAuditor, *testOutbox) {
+	repository := &testRepository{codes: map[uuid.UUID]map[string]bool{}}
+	auditor, outbox := &testAuditor{}, &testOutbox{}
+
+	return NewRecovery(
+		&config.Recovery{Count: config.RecoveryCountDefault, Length: config.RecoveryLengthDefault},
+		&config.Hash{Salt: "salt"},
+		&testGenerator{},
+		repository,
+		trace.NewNoopTracerProvider().Tracer(""),
+		auditor,
+		outbox,
+		&metrics.Metrics{HashDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "hash"}, []string{"algorithm"})},
+	), repository, auditor, outbox
+}
+
+func TestRecoveryGenerate(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		codes int
+		err   error
+		audit []domain.AuditAction
+	}{
+		{name: "count of the config", codes: config.RecoveryCountDefault, audit: []domain.AuditAction{domain.RecoveryGeneratedAction}},
+		{name: "requested count", count: 3, codes: 3, audit: []domain.AuditAction{domain.RecoveryGeneratedAction}},
+		{name: "maximum count", count: config.RecoveryCountMaximum, codes: config.RecoveryCountMaximum, audit: []domain.AuditAction{domain.RecoveryGeneratedAction}},
+		{name: "negative count", count: -1, err: InvalidCountError},
+		{name: "count over the maximum", count: config.RecoveryCountMaximum + 1, err: InvalidCountError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, repository, auditor, outbox := newTestRecovery()
+			login := uuid.New()
+
+			codes, err := service.Generate(context.Background(), login, test.count)
+			if err != test.err {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if len(codes) != test.codes {
+				t.Errorf("%d codes, want %d", len(codes), test.codes)
+			}
+
+			if stored := len(repository.codes[login]); stored != test.codes {
+				t.Errorf("%d stored codes, want %d", stored, test.codes)
+			}
+
+			for _, code := range codes {
+				if len(code) != config.RecoveryLengthDefault || strings.Trim(code, alphabet) != "" {
+					t.Errorf("code %q is not of the length and the alphabet", code)
+				}
+
+				if repository.codes[login][code] {
+					t.Errorf("code %q is stored in plaintext", code)
+				}
+			}
+
+			if !reflect.DeepEqual(auditor.actions, test.audit) {
+				t.Errorf("audit %v, want %v", auditor.actions, test.audit)
+			}
+
+			if !reflect.DeepEqual(outbox.actions, test.audit) {
+				t.Errorf("outbox %v, want %v", outbox.actions, test.audit)
+			}
+		})
+	}
+}
+
+func TestRecoveryUse(t *testing.T) {
+	service, _, auditor, outbox := newTestRecovery()
+	ctx := context.Background()
+	login := uuid.New()
+
+	previous, err := service.Generate(ctx, login, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := service.Generate(ctx, login, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formatted := strings.ToUpper(codes[1][:5] + "-" + codes[1][5:])
+
+	tests := []struct {
+		name      string
+		login     uuid.UUID
+		code      string
+		ok        bool
+		remaining int64
+	}{
+		{name: "valid code", login: login, code: codes[0], ok: true, remaining: 2},
+		{name: "spent code", login: login, code: codes[0], remaining: 2},
+		{name: "code of the revoked batch", login: login, code: previous[0], remaining: 2},
+		{name: "code of another login", login: uuid.New(), code: codes[2], remaining: 0},
+		{name: "formatted code", login: login, code: formatted, ok: true, remaining: 1},
+		{name: "unknown code", login: login, code: "unknown", remaining: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auditor.actions, outbox.actions = nil, nil
+
+			ok, err := service.Use(ctx, test.login, test.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != test.ok {
+				t.Errorf("used %t, want %t", ok, test.ok)
+			}
+
+			var audit []domain.AuditAction
+			if test.ok {
+				audit = []domain.AuditAction{domain.RecoveryUsedAction}
+			}
+
+			if !reflect.DeepEqual(auditor.actions, audit) || !reflect.DeepEqual(outbox.actions, audit) {
+				t.Errorf("audit %v and outbox %v, want %v", auditor.actions, outbox.actions, audit)
+			}
+
+			remaining, err := service.Remaining(ctx, test.login)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if remaining != test.remaining {
+				t.Errorf("%d remaining codes, want %d", remaining, test.remaining)
+			}
+		})
+	}
+
+	auditor.actions, outbox.actions = nil, nil
+
+	if revoked, err := service.Revoke(ctx, login); err != nil || revoked != 1 {
+		t.Errorf("%d revoked codes with error %v, want 1", revoked, err)
+	}
+
+	// nothing is revoked again, so nothing is recorded
+	if revoked, err := service.Revoke(ctx, login); err != nil || revoked != 0 {
+		t.Errorf("%d revoked codes with error %v of the repeated revoking, want 0", revoked, err)
+	}
+
+	if audit := []domain.AuditAction{domain.RecoveryRevokedAction}; !reflect.DeepEqual(auditor.actions, audit) || !reflect.DeepEqual(outbox.actions, audit) {
+		t.Errorf("audit %v and outbox %v, want %v", auditor.actions, outbox.actions, audit)
+	}
+}
@@ -0,0 +1,262 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/diez37/go-packages/log"
+	"github.com/go-http-utils/headers"
+	"github.com/google/uuid"
+	"github.com/ldez/mimetype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	goTime "time"
+)
+
+const (
+	IdHeaderName        = "X-Webhook-Id"
+	TimestampHeaderName = "X-Webhook-Timestamp"
+	SignatureHeaderName = "X-Webhook-Signature"
+
+	// limit count of deliveries sent at once
+	limit = 100
+
+	// errorLimit maximum length of the kept error of the attempt
+	errorLimit = 1024
+)
+
+var (
+	MissingSecretError = errors.New("webhook secret is required to sign payloads sent to webhook urls")
+)
+
+type Dispatcher interface {
+	// Send saving of the relayed event to the webhook outbox for every url when its action is in config.Webhook.Actions,
+	// the event is saved once for every url however many times it is relayed
+	Send(ctx context.Context, event *domain.OutboxEvent) error
+
+	// Deliver sending of due deliveries of the outbox, failed ones are retried with exponential backoff
+	// and moved to the dead-letter list after config.Webhook.Attempts attempts
+	Deliver(ctx context.Context) error
+
+	// Dead return the page of the dead-letter list and total count of it
+	Dead(ctx context.Context, page uint, limit uint) ([]*domain.Webhook, int64, error)
+
+	// Requeue return of the dead delivery to the outbox, false if it is not found in the dead-letter list
+	Requeue(ctx context.Context, uuid uuid.UUID) (bool, error)
+}
+
+type dispatcher struct {
+	actions map[domain.AuditAction]bool
+	client  *http.Client
+
+	config     *config.Webhook
+	repository repository.WebhookRepository
+	logger     log.Logger
+	tracer     trace.Tracer
+}
+
+// NewDispatcher return the dispatcher, MissingSecretError if urls are set without the secret,
+// receivers could not verify unsigned payloads
+func NewDispatcher(config *config.Webhook, repository repository.WebhookRepository, logger log.Logger, tracer trace.Tracer) (Dispatcher, error) {
+	if len(config.Urls) > 0 && config.Secret == "" {
+		return nil, MissingSecretError
+	}
+
+	actions := map[domain.AuditAction]bool{}
+	for _, action := range config.Actions {
+		actions[domain.AuditAction(action)] = true
+	}
+
+	return &dispatcher{
+		actions:    actions,
+		client:     &http.Client{Timeout: config.Timeout},
+		config:     config,
+		repository: repository,
+		logger:     logger,
+		tracer:     tracer,
+	}, nil
+}
+
+func (service *dispatcher) Send(ctx context.Context, event *domain.OutboxEvent) error {
+	ctx, span := service.tracer.Start(ctx, "Send")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "webhook"))
+
+	if len(service.config.Urls) == 0 || !service.actions[event.Action] {
+		return nil
+	}
+
+	webhooks := make([]*repository.Webhook, len(service.config.Urls))
+	for index, url := range service.config.Urls {
+		webhooks[index] = &repository.Webhook{Event: event.Uuid, Url: url, Payload: event.Payload}
+	}
+
+	_, err := service.repository.InsertWebhooks(ctx, webhooks...)
+
+	return err
+}
+
+func (service *dispatcher) Deliver(ctx context.Context) error {
+	ctx, span := service.tracer.Start(ctx, "Deliver")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "webhook"))
+
+	for {
+		webhooks, err := service.repository.DueWebhooks(ctx, time.NowUTC(), limit)
+		if err == db.RecordNotFoundError {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, webhook := range webhooks {
+			if err := service.deliver(ctx, webhook); err != nil {
+				return err
+			}
+		}
+
+		if len(webhooks) < limit {
+			return nil
+		}
+	}
+}
+
+// deliver sending of one delivery and saving of its result, only errors of saving are returned
+func (service *dispatcher) deliver(ctx context.Context, webhook *repository.Webhook) error {
+	err := service.send(ctx, webhook)
+	if err == nil {
+		return service.repository.DeliverWebhook(ctx, webhook.Uuid)
+	}
+
+	webhook.Attempts++
+	webhook.LastError = err.Error()
+	if len(webhook.LastError) > errorLimit {
+		webhook.LastError = webhook.LastError[:errorLimit]
+	}
+
+	next := time.NowUTC().Add(service.backoff(webhook.Attempts))
+	webhook.NextAttemptAt = &next
+
+	if webhook.Attempts >= service.config.Attempts {
+		webhook.Dead = true
+		service.logger.Errorf("webhook: delivery %s to %s is dead after %d attempts: %s", webhook.Uuid, webhook.Url, webhook.Attempts, err)
+	}
+
+	return service.repository.FailWebhook(ctx, webhook)
+}
+
+func (service *dispatcher) send(ctx context.Context, webhook *repository.Webhook) error {
+	ctx, span := service.tracer.Start(ctx, "send")
+	defer span.End()
+
+	timestamp := strconv.FormatInt(time.NowUTC().Unix(), 10)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewBufferString(webhook.Payload))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set(headers.ContentType, mimetype.ApplicationJSON)
+	request.Header.Set(IdHeaderName, webhook.Event.String())
+	request.Header.Set(TimestampHeaderName, timestamp)
+	request.Header.Set(SignatureHeaderName, Sign(service.config.Secret, timestamp, webhook.Payload))
+
+	response, err := service.client.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	// the body is read to reuse the connection
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// backoff delay before the next attempt after the count of failed attempts
+func (service *dispatcher) backoff(attempts int) goTime.Duration {
+	delay := service.config.Backoff
+	for attempt := 1; attempt < attempts && delay < service.config.BackoffMax; attempt++ {
+		delay *= 2
+	}
+
+	if delay > service.config.BackoffMax {
+		delay = service.config.BackoffMax
+	}
+
+	return delay
+}
+
+func (service *dispatcher) Dead(ctx context.Context, page uint, limit uint) ([]*domain.Webhook, int64, error) {
+	ctx, span := service.tracer.Start(ctx, "Dead")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "webhook"))
+
+	count, err := service.repository.CountDeadWebhooks(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	models, err := service.repository.PageDeadWebhooks(ctx, page, limit)
+	if err != nil && err != db.RecordNotFoundError {
+		return nil, 0, err
+	}
+
+	webhooks := make([]*domain.Webhook, len(models))
+	for index, model := range models {
+		webhooks[index] = &domain.Webhook{
+			Uuid:      model.Uuid,
+			Event:     model.Event,
+			Url:       model.Url,
+			Payload:   model.Payload,
+			Attempts:  model.Attempts,
+			LastError: model.LastError,
+			CreatedAt: model.CreatedAt,
+		}
+	}
+
+	return webhooks, count, nil
+}
+
+func (service *dispatcher) Requeue(ctx context.Context, uuid uuid.UUID) (bool, error) {
+	ctx, span := service.tracer.Start(ctx, "Requeue")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("service", "webhook"))
+
+	return service.repository.RequeueWebhook(ctx, uuid, time.NowUTC())
+}
+
+// Sign HMAC-SHA256 signature of the payload sent at the timestamp, the receiver computes it
+// over the value of TimestampHeaderName, "." and the body and compares with SignatureHeaderName
+func Sign(secret string, timestamp string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(payload))
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
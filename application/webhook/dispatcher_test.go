@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "webhook secret"
+
+// testRepository in-memory outbox of deliveries
+type testRepository struct {
+	mutex    sync.Mutex
+	webhooks []*repository.Webhook
+}
+
+func (fake *testRepository) InsertWebhooks(_ context.Context, webhooks ...*repository.Webhook) ([]*repository.Webhook, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	for _, webhook := range webhooks {
+		webhook.Uuid = uuid.New()
+		fake.webhooks = append(fake.webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (fake *testRepository) DueWebhooks(_ context.Context, now time.Time, limit uint) ([]*repository.Webhook, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	var due []*repository.Webhook
+	for _, webhook := range fake.webhooks {
+		if webhook.DeliveredAt == nil && !webhook.Dead && (webhook.NextAttemptAt == nil || !webhook.NextAttemptAt.After(now)) {
+			copied := *webhook
+			due = append(due, &copied)
+		}
+	}
+
+	if len(due) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	if uint(len(due)) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+func (fake *testRepository) find(uuid uuid.UUID) *repository.Webhook {
+	for _, webhook := range fake.webhooks {
+		if webhook.Uuid == uuid {
+			return webhook
+		}
+	}
+
+	return nil
+}
+
+func (fake *testRepository) DeliverWebhook(_ context.Context, uuid uuid.UUID) error {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	now := time.Now()
+	fake.find(uuid).DeliveredAt = &now
+
+	return nil
+}
+
+func (fake *testRepository) FailWebhook(_ context.Context, webhook *repository.Webhook) error {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	stored := fake.find(webhook.Uuid)
+	stored.Attempts = webhook.Attempts
+	stored.LastError = webhook.LastError
+	stored.NextAttemptAt = webhook.NextAttemptAt
+	stored.Dead = webhook.Dead
+
+	return nil
+}
+
+func (fake *testRepository) CountDeadWebhooks(context.Context) (int64, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	var count int64
+	for _, webhook := range fake.webhooks {
+		if webhook.Dead {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (fake *testRepository) PageDeadWebhooks(context.Context, uint, uint) ([]*repository.Webhook, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	var dead []*repository.Webhook
+	for _, webhook := range fake.webhooks {
+		if webhook.Dead {
+			dead = append(dead, webhook)
+		}
+	}
+
+	return dead, nil
+}
+
+func (fake *testRepository) RequeueWebhook(_ context.Context, uuid uuid.UUID, now time.Time) (bool, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	webhook := fake.find(uuid)
+	if webhook == nil || !webhook.Dead {
+		return false, nil
+	}
+
+	webhook.Dead = false
+	webhook.Attempts = 0
+	webhook.NextAttemptAt = &now
+
+	return true, nil
+}
+
+// testReceiver receiver of deliveries failing the first requests, signatures are verified independently of Sign
+type testReceiver struct {
+	mutex    sync.Mutex
+	failures int
+	requests int
+	invalid  int
+}
+
+func (receiver *testReceiver) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	receiver.mutex.Lock()
+	defer receiver.mutex.Unlock()
+
+	receiver.requests++
+
+	body, _ := ioutil.ReadAll(request.Body)
+
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(request.Header.Get(TimestampHeaderName) + "." + string(body)))
+
+	if request.Header.Get(SignatureHeaderName) != "sha256="+hex.EncodeToString(mac.Sum(nil)) || request.Header.Get(IdHeaderName) == "" {
+		receiver.invalid++
+	}
+
+	if receiver.requests <= receiver.failures {
+		writer.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func TestNewDispatcher(t *testing.T) {
+	tests := []struct {
+		name   string
+		urls   []string
+		secret string
+		err    error
+	}{
+		{name: "disabled webhooks"},
+		{name: "signed webhooks", urls: []string{"http://localhost"}, secret: testSecret},
+		{name: "unsigned webhooks", urls: []string{"http://localhost"}, err: MissingSecretError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			webhookConfig := &config.Webhook{Urls: test.urls, Secret: test.secret}
+
+			_, err := NewDispatcher(webhookConfig, &testRepository{}, logrus.New(), trace.NewNoopTracerProvider().Tracer(""))
+			if err != test.err {
+				t.Errorf("error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestDispatcherDeliver(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		backoff   time.Duration
+		action    domain.AuditAction
+		requests  int
+		delivered bool
+		dead      bool
+	}{
+		{name: "delivered at once", action: domain.DisabledAction, requests: 1, delivered: true},
+		{name: "delivered after retries", failures: 2, action: domain.DisabledAction, requests: 3, delivered: true},
+		{name: "dead after attempts", failures: 10, action: domain.DisabledAction, requests: 3, dead: true},
+		{name: "retry waits for backoff", failures: 1, backoff: time.Hour, action: domain.DisabledAction, requests: 1},
+		{name: "action is not sent", action: domain.AddAction},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			receiver := &testReceiver{failures: test.failures}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			backoff := test.backoff
+			if backoff == 0 {
+				backoff = time.Millisecond
+			}
+
+			repository := &testRepository{}
+			dispatcher, err := NewDispatcher(
+				&config.Webhook{
+					Urls:       []string{server.URL},
+					Secret:     testSecret,
+					Actions:    []string{string(domain.DisabledAction)},
+					Timeout:    time.Second,
+					Attempts:   3,
+					Backoff:    backoff,
+					BackoffMax: 2 * backoff,
+				},
+				repository,
+				logrus.New(),
+				trace.NewNoopTracerProvider().Tracer(""),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := context.Background()
+
+			event := &domain.OutboxEvent{Uuid: uuid.New(), Action: test.action, Payload: `{"action":"` + string(test.action) + `"}`}
+			if err := dispatcher.Send(ctx, event); err != nil {
+				t.Fatal(err)
+			}
+
+			// more rounds than attempts, dead deliveries must not be sent again
+			for round := 0; round < 6; round++ {
+				if err := dispatcher.Deliver(ctx); err != nil {
+					t.Fatal(err)
+				}
+
+				time.Sleep(3 * time.Millisecond)
+			}
+
+			if receiver.requests != test.requests {
+				t.Errorf("requests %d, want %d", receiver.requests, test.requests)
+			}
+
+			if receiver.invalid != 0 {
+				t.Errorf("%d requests with invalid signature", receiver.invalid)
+			}
+
+			if len(repository.webhooks) == 0 {
+				return
+			}
+
+			webhook := repository.webhooks[0]
+
+			if delivered := webhook.DeliveredAt != nil; delivered != test.delivered {
+				t.Errorf("delivered %t, want %t", delivered, test.delivered)
+			}
+
+			if webhook.Dead != test.dead {
+				t.Errorf("dead %t, want %t", webhook.Dead, test.dead)
+			}
+
+			dead, count, err := dispatcher.Dead(ctx, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (count == 1 && len(dead) == 1) != test.dead {
+				t.Errorf("dead-letter list of %d deliveries, want dead %t", count, test.dead)
+			}
+		})
+	}
+}
+
+func TestDispatcherBackoff(t *testing.T) {
+	service := &dispatcher{config: &config.Webhook{Backoff: 10 * time.Second, BackoffMax: time.Minute}}
+
+	tests := []struct {
+		attempts int
+		delay    time.Duration
+	}{
+		{attempts: 1, delay: 10 * time.Second},
+		{attempts: 2, delay: 20 * time.Second},
+		{attempts: 3, delay: 40 * time.Second},
+		{attempts: 4, delay: time.Minute},
+		{attempts: 20, delay: time.Minute},
+	}
+
+	for _, test := range tests {
+		if delay := service.backoff(test.attempts); delay != test.delay {
+			t.Errorf("backoff after %d attempts %s, want %s", test.attempts, delay, test.delay)
+		}
+	}
+}
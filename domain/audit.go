@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AddAction            AuditAction = "add"
+	CheckSuccessAction   AuditAction = "check_success"
+	CheckFailureAction   AuditAction = "check_failure"
+	ConsumedAction       AuditAction = "consumed"
+	ExpiredAction        AuditAction = "expired"
+	DisabledAction       AuditAction = "disabled"
+	PolicyRejectedAction AuditAction = "policy_rejected"
+
+	// recovery codes are not stored passwords, their events have no password
+	RecoveryGeneratedAction AuditAction = "recovery_generated"
+	RecoveryUsedAction      AuditAction = "recovery_used"
+	RecoveryRevokedAction   AuditAction = "recovery_revoked"
+)
+
+// AuditEntry event with the password, Password is uuid.Nil if the event is not related to a stored password
+type AuditEntry struct {
+	Uuid      uuid.UUID
+	Login     uuid.UUID
+	Password  uuid.UUID
+	Action    AuditAction
+	RequestId string
+	Address   string
+	CreatedAt *time.Time
+}
+
+// AuditVerification result of walking of the chain of audit entries
+type AuditVerification struct {
+	// Entries count of verified chained entries
+	Entries int64
+
+	// Checkpoints count of verified signed checkpoints
+	Checkpoints int64
+
+	// Unchained count of entries written before chaining was enabled
+	Unchained int64
+
+	// Break the first found break of the chain, nil if the chain is intact
+	Break *AuditBreak
+}
+
+type AuditBreak struct {
+	Id     int64
+	Uuid   uuid.UUID
+	Reason string
+}
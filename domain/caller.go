@@ -0,0 +1,28 @@
+package domain
+
+type Role string
+
+const (
+	// CheckRole allows checking of credentials only
+	CheckRole Role = "check"
+
+	// AdminRole allows every operation of the api
+	AdminRole Role = "admin"
+)
+
+// Caller authenticated client of the api
+type Caller struct {
+	Identity string
+	Roles    []Role
+}
+
+// Has whether the caller is allowed to act with the role, the administrator is allowed everything
+func (caller *Caller) Has(role Role) bool {
+	for _, granted := range caller.Roles {
+		if granted == role || granted == AdminRole {
+			return true
+		}
+	}
+
+	return false
+}
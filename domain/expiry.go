@@ -0,0 +1,7 @@
+package domain
+
+// ExpiryEvent notification that the validity of the password ends in Threshold days or earlier
+type ExpiryEvent struct {
+	Password  *Password
+	Threshold int
+}
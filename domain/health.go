@@ -0,0 +1,22 @@
+package domain
+
+const (
+	UpHealthStatus   HealthStatus = "up"
+	DownHealthStatus HealthStatus = "down"
+)
+
+// HealthStatus state of the service or one of its checks
+type HealthStatus string
+
+// HealthCheck result of one check of the service, Message explains the status
+type HealthCheck struct {
+	Name    string
+	Status  HealthStatus
+	Message string
+}
+
+// HealthReport results of all checks, the service is up only if every check is up
+type HealthReport struct {
+	Status HealthStatus
+	Checks []*HealthCheck
+}
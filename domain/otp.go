@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type OtpKind string
+
+const (
+	TotpKind OtpKind = "totp"
+	HotpKind OtpKind = "hotp"
+)
+
+type Otp struct {
+	Uuid      uuid.UUID
+	Login     uuid.UUID
+	Kind      OtpKind
+	Secret    []byte
+	Algorithm string
+	Digits    int
+	Period    time.Duration
+	Counter   int64
+	Disabled  bool
+	CreatedAt *time.Time
+	UpdateAt  *time.Time
+}
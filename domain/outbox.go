@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// OutboxEvent event of the credential change, Uuid is the idempotency key, it is the same for every delivery
+// of the event, Payload is the json body sent to sinks
+type OutboxEvent struct {
+	Uuid      uuid.UUID
+	Action    AuditAction
+	Login     uuid.UUID
+	Password  uuid.UUID
+	RequestId string
+	Payload   string
+	CreatedAt *time.Time
+}
@@ -5,6 +5,18 @@ import (
 	"time"
 )
 
+type PasswordType string
+
+const (
+	PrimaryType   PasswordType = "primary"
+	AppType       PasswordType = "app"
+	TemporaryType PasswordType = "temporary"
+	TokenType     PasswordType = "token"
+
+	// ResetType token for resetting of passwords of the login, it is not accepted as a password
+	ResetType PasswordType = "reset"
+)
+
 type Password struct {
 	Uuid       uuid.UUID
 	Login      uuid.UUID
@@ -14,4 +26,25 @@ type Password struct {
 	CreatedAt  *time.Time
 	UpdateAt   *time.Time
 	ValidUntil *time.Time
+	Type       PasswordType
+
+	// Scopes where the password can be used, the password without scopes can be used everywhere,
+	// on checking these are scopes required by the caller
+	Scopes []string
+	Label  string
+
+	// MaxUses count of successful checks after which the password is disabled, 0 is unlimited
+	MaxUses    int64
+	UseCount   int64
+	LastUsedAt *time.Time
+
+	// ValidFrom time from which the password is active, nil is active right after adding
+	ValidFrom *time.Time
+
+	// MustChange the password is valid once, the user must set a new one after using it
+	MustChange bool
+
+	// ExpiredGrace the validity of the password is ended, but it is accepted in the grace period,
+	// it is set only by checking
+	ExpiredGrace bool
 }
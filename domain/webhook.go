@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Webhook delivery of the event to one receiver, Event is the uuid of the audit entry and is the same for all receivers
+type Webhook struct {
+	Uuid      uuid.UUID
+	Event     uuid.UUID
+	Url       string
+	Payload   string
+	Attempts  int
+	LastError string
+	CreatedAt *time.Time
+}
@@ -0,0 +1,151 @@
+package certificates
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/diez37/go-packages/log"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	InvalidClientCaError = errors.New("no certificates in the client ca file")
+)
+
+// Certificates tls configuration of servers, files are loaded again when they are modified,
+// established connections keep the configuration of their handshake
+type Certificates struct {
+	mutex    sync.RWMutex
+	current  *tls.Config
+	modified map[string]time.Time
+
+	config *config.Tls
+}
+
+func NewCertificates(config *config.Tls) (*Certificates, error) {
+	certificates := &Certificates{config: config, modified: map[string]time.Time{}}
+
+	if _, err := certificates.Reload(); err != nil {
+		return nil, err
+	}
+
+	return certificates, nil
+}
+
+// Config configuration of the server, every handshake takes the current one, the current certificate
+// is also returned by GetCertificate, servers refuse to start tls without any source of certificates
+func (certificates *Certificates) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+			certificates.mutex.RLock()
+			defer certificates.mutex.RUnlock()
+
+			return certificates.current, nil
+		},
+		GetCertificate: func(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			certificates.mutex.RLock()
+			defer certificates.mutex.RUnlock()
+
+			return &certificates.current.Certificates[0], nil
+		},
+	}
+}
+
+// Watch reloading of files every config.Tls.ReloadInterval until the context is done,
+// errors of loading are logged and the current configuration is kept
+func (certificates *Certificates) Watch(ctx context.Context, logger log.Logger) {
+	if certificates.config.ReloadInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(certificates.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := certificates.Reload()
+			if err != nil {
+				logger.Error(err)
+				continue
+			}
+
+			if reloaded {
+				logger.Infof("tls: certificates reloaded")
+			}
+		}
+	}
+}
+
+// Reload loading of files if any of them is modified since the last loading, true if they are loaded,
+// the current configuration is kept if loading fails
+func (certificates *Certificates) Reload() (bool, error) {
+	files := []string{certificates.config.CertFile, certificates.config.KeyFile}
+	if certificates.config.ClientCaFile != "" {
+		files = append(files, certificates.config.ClientCaFile)
+	}
+
+	modified := map[string]time.Time{}
+	changed := false
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+
+		modified[file] = info.ModTime()
+		changed = changed || !info.ModTime().Equal(certificates.modified[file])
+	}
+
+	if !changed {
+		return false, nil
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certificates.config.CertFile, certificates.config.KeyFile)
+	if err != nil {
+		return false, err
+	}
+
+	current := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.NoClientCert,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+
+	if certificates.config.ClientCaFile != "" {
+		content, err := ioutil.ReadFile(certificates.config.ClientCaFile)
+		if err != nil {
+			return false, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return false, InvalidClientCaError
+		}
+
+		current.ClientCAs = pool
+		current.ClientAuth = tls.VerifyClientCertIfGiven
+
+		if certificates.config.ClientRequired {
+			current.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	certificates.mutex.Lock()
+	defer certificates.mutex.Unlock()
+
+	certificates.current = current
+	certificates.modified = modified
+
+	return true, nil
+}
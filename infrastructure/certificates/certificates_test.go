@@ -0,0 +1,349 @@
+package certificates
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testAuthority self-signed authority issuing certificates of tests
+type testAuthority struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newTestAuthority(t *testing.T) *testAuthority {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test authority"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	content, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certificate, err := x509.ParseCertificate(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testAuthority{
+		certificate: certificate,
+		key:         key,
+		pem:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: content}),
+	}
+}
+
+// issue PEM certificate and key of localhost with the serial number
+func (authority *testAuthority) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	content, err := x509.CreateCertificate(rand.Reader, template, authority.certificate, &key.PublicKey, authority.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyContent, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: content}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyContent})
+}
+
+// writeFile writing of the file with the modification time in the future of the previous writing,
+// the resolution of modification times of some file systems is coarser than the duration of tests
+func writeFile(t *testing.T, file string, content []byte, modified time.Time) {
+	t.Helper()
+
+	if err := ioutil.WriteFile(file, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chtimes(file, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// serve starting of the http server over tls like interface/http does, return the address of the server
+func serve(t *testing.T, certificates *Certificates) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(writer http.ResponseWriter, _ *http.Request) {
+			writer.WriteHeader(http.StatusNoContent)
+		}),
+		TLSConfig: certificates.Config(),
+	}
+
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+
+	return listener.Addr().String()
+}
+
+// serial serial number of the certificate presented by the server on a new connection
+func serial(t *testing.T, address string, roots *x509.CertPool) int64 {
+	t.Helper()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots, ServerName: "localhost"},
+		DisableKeepAlives: true,
+	}}
+
+	response, err := client.Get("https://" + address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	return response.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestCertificatesReload(t *testing.T) {
+	authority := newTestAuthority(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(authority.certificate)
+
+	directory := t.TempDir()
+	tlsConfig := &config.Tls{CertFile: filepath.Join(directory, "cert.pem"), KeyFile: filepath.Join(directory, "key.pem")}
+	modified := time.Now().Add(-time.Minute)
+
+	certificate, key := authority.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	writeFile(t, tlsConfig.CertFile, certificate, modified)
+	writeFile(t, tlsConfig.KeyFile, key, modified)
+
+	certificates, err := NewCertificates(tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	address := serve(t, certificates)
+
+	tests := []struct {
+		name     string
+		write    func(modified time.Time)
+		reloaded bool
+		err      bool
+		serial   int64
+	}{
+		{
+			name:   "initial certificate",
+			write:  func(time.Time) {},
+			serial: 10,
+		},
+		{
+			name: "swapped certificate",
+			write: func(modified time.Time) {
+				certificate, key := authority.issue(t, 20, x509.ExtKeyUsageServerAuth)
+				writeFile(t, tlsConfig.CertFile, certificate, modified)
+				writeFile(t, tlsConfig.KeyFile, key, modified)
+			},
+			reloaded: true,
+			serial:   20,
+		},
+		{
+			name:   "unchanged files",
+			write:  func(time.Time) {},
+			serial: 20,
+		},
+		{
+			name: "broken certificate keeps the current one",
+			write: func(modified time.Time) {
+				writeFile(t, tlsConfig.CertFile, []byte("broken"), modified)
+			},
+			err:    true,
+			serial: 20,
+		},
+		{
+			name: "repaired certificate",
+			write: func(modified time.Time) {
+				certificate, key := authority.issue(t, 30, x509.ExtKeyUsageServerAuth)
+				writeFile(t, tlsConfig.CertFile, certificate, modified)
+				writeFile(t, tlsConfig.KeyFile, key, modified)
+			},
+			reloaded: true,
+			serial:   30,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modified = modified.Add(time.Second)
+			test.write(modified)
+
+			reloaded, err := certificates.Reload()
+			if (err != nil) != test.err {
+				t.Fatalf("error %v, want error %t", err, test.err)
+			}
+
+			if reloaded != test.reloaded {
+				t.Errorf("reloaded %t, want %t", reloaded, test.reloaded)
+			}
+
+			if serial := serial(t, address, roots); serial != test.serial {
+				t.Errorf("serial %d, want %d", serial, test.serial)
+			}
+		})
+	}
+}
+
+func TestCertificatesClient(t *testing.T) {
+	authority := newTestAuthority(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(authority.certificate)
+
+	directory := t.TempDir()
+	modified := time.Now()
+
+	certificate, key := authority.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	writeFile(t, filepath.Join(directory, "cert.pem"), certificate, modified)
+	writeFile(t, filepath.Join(directory, "key.pem"), key, modified)
+	writeFile(t, filepath.Join(directory, "ca.pem"), authority.pem, modified)
+	writeFile(t, filepath.Join(directory, "empty.pem"), []byte{}, modified)
+
+	clientCertificate, clientKey := authority.issue(t, 11, x509.ExtKeyUsageClientAuth)
+	client, err := tls.X509KeyPair(clientCertificate, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		caFile      string
+		required    bool
+		certificate bool
+		configErr   error
+		verified    bool
+		handshake   bool
+	}{
+		{name: "client certificates disabled", certificate: true, handshake: true},
+		{name: "optional without certificate", caFile: "ca.pem", handshake: true},
+		{name: "optional with certificate", caFile: "ca.pem", certificate: true, verified: true, handshake: true},
+		{name: "required without certificate", caFile: "ca.pem", required: true},
+		{name: "required with certificate", caFile: "ca.pem", required: true, certificate: true, verified: true, handshake: true},
+		{name: "empty authorities", caFile: "empty.pem", configErr: InvalidClientCaError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tlsConfig := &config.Tls{
+				CertFile:       filepath.Join(directory, "cert.pem"),
+				KeyFile:        filepath.Join(directory, "key.pem"),
+				ClientRequired: test.required,
+			}
+
+			if test.caFile != "" {
+				tlsConfig.ClientCaFile = filepath.Join(directory, test.caFile)
+			}
+
+			certificates, err := NewCertificates(tlsConfig)
+			if err != test.configErr {
+				t.Fatalf("error %v, want %v", err, test.configErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			listener, err := tls.Listen("tcp", "127.0.0.1:0", certificates.Config())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer listener.Close()
+
+			// outcome of the handshake on the server side, tls 1.3 clients finish the handshake before
+			// the server verifies their certificate
+			type outcome struct {
+				handshake bool
+				verified  bool
+			}
+
+			outcomes := make(chan outcome, 1)
+			go func() {
+				connection, err := listener.Accept()
+				if err != nil {
+					outcomes <- outcome{}
+					return
+				}
+				defer connection.Close()
+
+				tlsConnection := connection.(*tls.Conn)
+				if err := tlsConnection.Handshake(); err != nil {
+					outcomes <- outcome{}
+					return
+				}
+
+				outcomes <- outcome{handshake: true, verified: len(tlsConnection.ConnectionState().VerifiedChains) > 0}
+			}()
+
+			clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+			if test.certificate {
+				clientConfig.Certificates = []tls.Certificate{client}
+			}
+
+			if connection, err := tls.Dial("tcp", listener.Addr().String(), clientConfig); err == nil {
+				connection.Close()
+			}
+
+			result := <-outcomes
+
+			if result.handshake != test.handshake {
+				t.Errorf("handshake %t, want %t", result.handshake, test.handshake)
+			}
+
+			if result.verified != test.verified {
+				t.Errorf("verified %t, want %t", result.verified, test.verified)
+			}
+		})
+	}
+}
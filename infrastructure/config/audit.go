@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+const (
+	AuditKeyFileFieldName            = "audit.key.file"
+	AuditCheckpointIntervalFieldName = "audit.checkpoint.interval"
+
+	AuditKeyFileDefault            = "./audit.key"
+	AuditCheckpointIntervalDefault = time.Hour
+)
+
+type Audit struct {
+	// KeyFile file with the base64 encoded Ed25519 seed for signing of checkpoints, the server creates it if missing,
+	// the verification requires the existing one
+	KeyFile string
+
+	// CheckpointInterval interval between signed checkpoints of the chain of audit entries
+	CheckpointInterval time.Duration
+}
+
+func NewAudit() *Audit {
+	return &Audit{}
+}
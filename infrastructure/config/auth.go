@@ -0,0 +1,81 @@
+package config
+
+import "time"
+
+const (
+	AuthMethodsFieldName        = "auth.methods"
+	AuthDisabledFieldName       = "auth.disabled"
+	AuthKeysFieldName           = "auth.keys"
+	AuthHmacSecretsFieldName    = "auth.hmac.secrets"
+	AuthHmacSkewFieldName       = "auth.hmac.skew"
+	AuthJwtJwksFieldName        = "auth.jwt.jwks"
+	AuthJwtIssuerFieldName      = "auth.jwt.issuer"
+	AuthJwtAudienceFieldName    = "auth.jwt.audience"
+	AuthRolesFieldName          = "auth.roles"
+	AuthMtlsIdentitiesFieldName = "auth.mtls.identities"
+
+	// ApiKeyAuthMethod the caller passes the static key in the X-Api-Key header
+	ApiKeyAuthMethod = "apikey"
+
+	// HmacAuthMethod the caller signs the method, the path, the timestamp, the nonce and the body of the request with the shared secret
+	HmacAuthMethod = "hmac"
+
+	// JwtAuthMethod the caller passes the bearer token signed by one of keys of the JWKS file
+	JwtAuthMethod = "jwt"
+
+	// MtlsAuthMethod the caller presents the client certificate verified by config.Tls ClientCaFile
+	MtlsAuthMethod = "mtls"
+
+	AuthDisabledDefault    = false
+	AuthHmacSkewDefault    = 5 * time.Minute
+	AuthJwtJwksDefault     = ""
+	AuthJwtIssuerDefault   = ""
+	AuthJwtAudienceDefault = ""
+)
+
+var (
+	AuthMethodsDefault        = []string{}
+	AuthKeysDefault           = map[string]string{}
+	AuthHmacSecretsDefault    = map[string]string{}
+	AuthRolesDefault          = map[string]string{}
+	AuthMtlsIdentitiesDefault = map[string]string{}
+)
+
+type Auth struct {
+	// Methods accepted methods of caller authentication, required unless Disabled
+	Methods []string
+
+	// Disabled disabling of authentication, every caller is the administrator, Methods must be empty
+	Disabled bool
+
+	// Keys hex encoded SHA-256 hashes of static api keys by identities of callers
+	Keys map[string]string
+
+	// HmacSecrets shared secrets of signed requests by identities of callers
+	HmacSecrets map[string]string
+
+	// HmacSkew maximum difference between the timestamp of the signed request and the current time,
+	// nonces of signed requests are remembered for the same time
+	HmacSkew time.Duration
+
+	// JwtJwks file of the JSON Web Key Set verifying bearer tokens, RS256 and ES256 keys are supported
+	JwtJwks string
+
+	// JwtIssuer required iss claim of bearer tokens, empty skips the check
+	JwtIssuer string
+
+	// JwtAudience required aud claim of bearer tokens, empty skips the check
+	JwtAudience string
+
+	// MtlsIdentities identities of callers by names of client certificates: cn:<common name>,
+	// dns:<name>, uri:<uri> or email:<address> of subject alternative names, unmapped certificates are not callers
+	MtlsIdentities map[string]string
+
+	// Roles roles by identities of callers namespaced by methods: apikey:<name>, hmac:<id>, jwt:<sub>
+	// or mtls:<name>, check or admin, bearer tokens may carry roles in the roles claim
+	Roles map[string]string
+}
+
+func NewAuth() *Auth {
+	return &Auth{}
+}
@@ -3,13 +3,16 @@ package config
 import "time"
 
 const (
-	BlockerBlockIntervalFieldName = "blocker.interval"
+	BlockerBlockIntervalFieldName  = "blocker.interval"
+	BlockerStatusLifetimeFieldName = "blocker.status.lifetime"
 
-	BlockerBlockIntervalDefault = 10 * time.Second
+	BlockerBlockIntervalDefault  = 10 * time.Second
+	BlockerStatusLifetimeDefault = time.Hour
 )
 
 type Blocker struct {
-	BlockInterval time.Duration
+	BlockInterval  time.Duration
+	StatusLifetime time.Duration
 }
 
 func NewBlocker() *Blocker {
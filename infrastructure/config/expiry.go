@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+const (
+	ExpiryIntervalFieldName   = "expiry.interval"
+	ExpiryThresholdsFieldName = "expiry.thresholds"
+
+	ExpiryIntervalDefault = time.Hour
+)
+
+var (
+	ExpiryThresholdsDefault = []int{30, 7, 1}
+)
+
+type Expiry struct {
+	// Interval between searches of expiring passwords for notification
+	Interval time.Duration
+
+	// Thresholds days before the end of validity when the notification is sent, empty disables notifications
+	Thresholds []int
+}
+
+func NewExpiry() *Expiry {
+	return &Expiry{}
+}
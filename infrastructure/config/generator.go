@@ -0,0 +1,16 @@
+package config
+
+const (
+	GeneratorWordsFileFieldName = "generator.words.file"
+
+	GeneratorWordsFileDefault = ""
+)
+
+type Generator struct {
+	// WordsFile path to the file with words for passphrases, one word per line, the built-in list is used if empty
+	WordsFile string
+}
+
+func NewGenerator() *Generator {
+	return &Generator{}
+}
@@ -0,0 +1,16 @@
+package config
+
+const (
+	GrpcAddressFieldName = "grpc.address"
+
+	GrpcAddressDefault = ":9090"
+)
+
+type Grpc struct {
+	// Address listening address of the grpc server, empty disables the server
+	Address string
+}
+
+func NewGrpc() *Grpc {
+	return &Grpc{}
+}
@@ -0,0 +1,17 @@
+package config
+
+const (
+	HealthRepeaterIntervalsFieldName = "health.repeater.intervals"
+
+	HealthRepeaterIntervalsDefault = 3
+)
+
+type Health struct {
+	// RepeaterIntervals count of intervals of a task of the repeater without a completed cycle
+	// after which the service is not ready, every task is measured by its own interval
+	RepeaterIntervals int
+}
+
+func NewHealth() *Health {
+	return &Health{}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	OtpKeyFieldName       = "otp.key"
+	OtpIssuerFieldName    = "otp.issuer"
+	OtpSkewFieldName      = "otp.skew"
+	OtpLookAheadFieldName = "otp.look_ahead"
+	OtpPeriodFieldName    = "otp.period"
+	OtpDigitsFieldName    = "otp.digits"
+
+	OtpKeyDefault       = ""
+	OtpIssuerDefault    = "passwords"
+	OtpSkewDefault      = 1
+	OtpLookAheadDefault = 10
+	OtpPeriodDefault    = 30 * time.Second
+	OtpDigitsDefault    = 6
+
+	// OtpDigitsMin and OtpDigitsMax bounds of digits of codes by RFC 4226
+	OtpDigitsMin = 6
+	OtpDigitsMax = 8
+)
+
+var (
+	MissingOtpKeyError    = errors.New("otp.key is not set")
+	InvalidOtpPeriodError = errors.New("otp.period must be a whole number of seconds, at least one")
+	InvalidOtpDigitsError = errors.New("otp.digits must be in [6, 8]")
+)
+
+type Otp struct {
+	// Key secret for encryption of otp secrets at rest, secrets are not readable with another key,
+	// empty disables otp
+	Key string
+
+	// Issuer name of the service in otpauth:// uri
+	Issuer string
+
+	// Skew count of TOTP time steps accepted before and after the current one
+	Skew int
+
+	// LookAhead count of HOTP counter values accepted after the expected one
+	LookAhead int
+
+	// Period TOTP time step, stored in whole seconds
+	Period time.Duration
+
+	// Digits count of digits of codes of enrolled secrets without explicit digits
+	Digits int
+}
+
+func NewOtp() *Otp {
+	return &Otp{}
+}
+
+// Enabled whether otp is configured, without Key the otp service is not created
+func (otp *Otp) Enabled() bool {
+	return otp.Key != ""
+}
+
+// Validate checking of values the otp service cannot work with
+func (otp *Otp) Validate() error {
+	if otp.Key == "" {
+		return MissingOtpKeyError
+	}
+
+	if otp.Period < time.Second || otp.Period%time.Second != 0 {
+		return InvalidOtpPeriodError
+	}
+
+	if otp.Digits < OtpDigitsMin || otp.Digits > OtpDigitsMax {
+		return InvalidOtpDigitsError
+	}
+
+	return nil
+}
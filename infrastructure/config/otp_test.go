@@ -0,0 +1,36 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOtpValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		period time.Duration
+		digits int
+		err    error
+	}{
+		{name: "defaults with key", key: "key", period: OtpPeriodDefault, digits: OtpDigitsDefault},
+		{name: "without key", period: OtpPeriodDefault, digits: OtpDigitsDefault, err: MissingOtpKeyError},
+		{name: "zero period", key: "key", digits: OtpDigitsDefault, err: InvalidOtpPeriodError},
+		{name: "period under a second", key: "key", period: 500 * time.Millisecond, digits: OtpDigitsDefault, err: InvalidOtpPeriodError},
+		{name: "fractional period", key: "key", period: 1500 * time.Millisecond, digits: OtpDigitsDefault, err: InvalidOtpPeriodError},
+		{name: "period of a second", key: "key", period: time.Second, digits: OtpDigitsDefault},
+		{name: "zero digits", key: "key", period: OtpPeriodDefault, err: InvalidOtpDigitsError},
+		{name: "too many digits", key: "key", period: OtpPeriodDefault, digits: 10, err: InvalidOtpDigitsError},
+		{name: "maximum digits", key: "key", period: OtpPeriodDefault, digits: OtpDigitsMax},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			otp := &Otp{Key: test.key, Period: test.period, Digits: test.digits}
+
+			if err := otp.Validate(); err != test.err {
+				t.Errorf("error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
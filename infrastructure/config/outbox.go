@@ -0,0 +1,35 @@
+package config
+
+import "time"
+
+const (
+	OutboxSinksFieldName    = "outbox.sinks"
+	OutboxFileFieldName     = "outbox.file"
+	OutboxIntervalFieldName = "outbox.interval"
+
+	LogOutboxSink     = "log"
+	FileOutboxSink    = "file"
+	WebhookOutboxSink = "webhook"
+
+	OutboxFileDefault     = "./outbox.jsonl"
+	OutboxIntervalDefault = 5 * time.Second
+)
+
+var (
+	OutboxSinksDefault = []string{WebhookOutboxSink}
+)
+
+type Outbox struct {
+	// Sinks receivers of relayed events: log, file or webhook, every sink receives every event
+	Sinks []string
+
+	// File of the file sink, events are appended as json lines
+	File string
+
+	// Interval between relays of the outbox
+	Interval time.Duration
+}
+
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
@@ -3,13 +3,58 @@ package config
 import "time"
 
 const (
-	PasswordLifetimeFieldName = "password.lifetime"
+	PasswordLifetimeFieldName         = "password.lifetime"
+	PasswordBatchLimitFieldName       = "password.batch.limit"
+	PasswordBatchParallelismFieldName = "password.batch.parallelism"
+	PasswordResetLifetimeFieldName    = "password.reset.lifetime"
+	PasswordGracePeriodFieldName      = "password.grace.period"
+	PasswordActiveLimitFieldName      = "password.active.limit"
+	PasswordActiveLimitsFieldName     = "password.active.limits"
+	PasswordActiveOverflowFieldName   = "password.active.overflow"
 
-	PasswordLifetimeDefault = 2 * 12 * 30 * 24 * time.Hour
+	// PasswordRejectOverflow the password exceeding the limit of active passwords is not added
+	PasswordRejectOverflow = "reject"
+
+	// PasswordEvictOverflow the oldest active passwords are disabled to add the password exceeding the limit
+	PasswordEvictOverflow = "evict"
+
+	PasswordLifetimeDefault         = 2 * 12 * 30 * 24 * time.Hour
+	PasswordBatchLimitDefault       = 1000
+	PasswordBatchParallelismDefault = 8
+	PasswordResetLifetimeDefault    = 15 * time.Minute
+	PasswordGracePeriodDefault      = time.Duration(0)
+	PasswordActiveLimitDefault      = 0
+	PasswordActiveOverflowDefault   = PasswordRejectOverflow
+)
+
+var (
+	PasswordActiveLimitsDefault = map[string]int{}
 )
 
 type Password struct {
 	Lifetime time.Duration
+
+	// BatchLimit maximum count of passwords in one batch request
+	BatchLimit int
+
+	// BatchParallelism maximum count of passwords hashing or checking at the same time in one batch
+	BatchParallelism int
+
+	// ResetLifetime time during which the reset token can be redeemed
+	ResetLifetime time.Duration
+
+	// GracePeriod time after the end of validity during which the password is still accepted
+	// with the flag of expiration, the password is blocked only after it
+	GracePeriod time.Duration
+
+	// ActiveLimit maximum count of active passwords of one login, 0 is unlimited
+	ActiveLimit int
+
+	// ActiveLimits maximum count of active passwords of one login by types of passwords
+	ActiveLimits map[string]int
+
+	// ActiveOverflow strategy on exceeding of limits, PasswordRejectOverflow or PasswordEvictOverflow
+	ActiveOverflow string
 }
 
 func NewPassword() *Password {
@@ -0,0 +1,22 @@
+package config
+
+const (
+	RecoveryCountFieldName  = "recovery.count"
+	RecoveryLengthFieldName = "recovery.length"
+
+	RecoveryCountDefault  = 10
+	RecoveryCountMaximum  = 100
+	RecoveryLengthDefault = 10
+)
+
+type Recovery struct {
+	// Count of codes in one batch if the count is not passed in request
+	Count int
+
+	// Length of one code
+	Length int
+}
+
+func NewRecovery() *Recovery {
+	return &Recovery{}
+}
@@ -0,0 +1,40 @@
+package config
+
+import "time"
+
+const (
+	TlsCertFileFieldName       = "tls.cert.file"
+	TlsKeyFileFieldName        = "tls.key.file"
+	TlsClientCaFileFieldName   = "tls.client.ca.file"
+	TlsClientRequiredFieldName = "tls.client.required"
+	TlsReloadIntervalFieldName = "tls.reload.interval"
+
+	TlsCertFileDefault       = ""
+	TlsKeyFileDefault        = ""
+	TlsClientCaFileDefault   = ""
+	TlsClientRequiredDefault = false
+	TlsReloadIntervalDefault = 30 * time.Second
+)
+
+type Tls struct {
+	// CertFile PEM certificate chain of the server, empty serves plain http
+	CertFile string
+
+	// KeyFile PEM private key of the server certificate
+	KeyFile string
+
+	// ClientCaFile PEM bundle of authorities of client certificates, empty disables client certificates
+	ClientCaFile string
+
+	// ClientRequired whether connections without the verified client certificate are rejected,
+	// otherwise the certificate is verified only if it is given
+	ClientRequired bool
+
+	// ReloadInterval interval between checks of modification of files, changed files are loaded
+	// for new connections, 0 disables reloading
+	ReloadInterval time.Duration
+}
+
+func NewTls() *Tls {
+	return &Tls{}
+}
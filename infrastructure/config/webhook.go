@@ -0,0 +1,54 @@
+package config
+
+import "time"
+
+const (
+	WebhookUrlsFieldName       = "webhook.urls"
+	WebhookSecretFieldName     = "webhook.secret"
+	WebhookActionsFieldName    = "webhook.actions"
+	WebhookIntervalFieldName   = "webhook.interval"
+	WebhookTimeoutFieldName    = "webhook.timeout"
+	WebhookAttemptsFieldName   = "webhook.attempts"
+	WebhookBackoffFieldName    = "webhook.backoff"
+	WebhookBackoffMaxFieldName = "webhook.backoff.max"
+
+	WebhookSecretDefault     = ""
+	WebhookIntervalDefault   = 10 * time.Second
+	WebhookTimeoutDefault    = 5 * time.Second
+	WebhookAttemptsDefault   = 10
+	WebhookBackoffDefault    = 10 * time.Second
+	WebhookBackoffMaxDefault = time.Hour
+)
+
+var (
+	WebhookUrlsDefault    = []string{}
+	WebhookActionsDefault = []string{"disabled", "consumed"}
+)
+
+type Webhook struct {
+	// Urls receivers of events, every event is delivered to every url, empty disables webhooks
+	Urls []string
+
+	// Secret key of HMAC-SHA256 signature of payloads, required when Urls are set
+	Secret string
+
+	// Actions audit actions sent as events
+	Actions []string
+
+	// Interval between deliveries of the outbox
+	Interval time.Duration
+
+	// Timeout of one delivery request
+	Timeout time.Duration
+
+	// Attempts count of failed attempts after which the delivery is moved to the dead-letter list
+	Attempts int
+
+	// Backoff delay after the first failed attempt, it is doubled after every next one up to BackoffMax
+	Backoff    time.Duration
+	BackoffMax time.Duration
+}
+
+func NewWebhook() *Webhook {
+	return &Webhook{}
+}
@@ -2,6 +2,7 @@ package container
 
 import (
 	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/metrics"
 	"github.com/Diez37/passwords/infrastructure/repository"
 	"github.com/diez37/go-packages/container"
 	"github.com/go-playground/validator/v10"
@@ -10,9 +11,26 @@ import (
 func AddProvide(container container.Container) error {
 	return container.Provides(
 		repository.NewSql,
+		repository.NewOtpSql,
+		repository.NewRecoverySql,
+		repository.NewAuditSql,
+		repository.NewWebhookSql,
+		repository.NewOutboxSql,
 		config.NewHash,
 		config.NewPassword,
 		config.NewBlocker,
+		config.NewGenerator,
+		config.NewOtp,
+		config.NewRecovery,
+		config.NewExpiry,
+		config.NewAudit,
+		config.NewHealth,
+		config.NewWebhook,
+		config.NewOutbox,
+		config.NewAuth,
+		config.NewTls,
+		config.NewGrpc,
+		metrics.NewMetrics,
 		validator.New,
 	)
 }
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"github.com/diez37/go-packages/app"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	OkOutcome      = "ok"
+	WrongOutcome   = "wrong"
+	ExpiredOutcome = "expired"
+	LockedOutcome  = "locked"
+	ExistsOutcome  = "exists"
+	LimitOutcome   = "limit"
+	ErrorOutcome   = "error"
+
+	BcryptAlgorithm     = "bcrypt"
+	HmacSha256Algorithm = "hmac_sha256"
+
+	BlockTask      = "block"
+	ExpiryTask     = "expiry"
+	CheckpointTask = "checkpoint"
+	WebhookTask    = "webhook"
+	OutboxTask     = "outbox"
+)
+
+// Metrics container for prometheus metrics of the service, they are exposed by '/metrics' of the router
+type Metrics struct {
+	// CheckTotal number of checks of passwords by outcomes: ok, wrong, expired, locked, error
+	CheckTotal *prometheus.CounterVec
+
+	// AddTotal number of additions of passwords by outcomes: ok, exists, limit, error
+	AddTotal *prometheus.CounterVec
+
+	// HashDuration duration of hashing and comparison of hashes by algorithms
+	HashDuration *prometheus.HistogramVec
+
+	// BlockerQueue number of passwords waiting for disabling by the blocker
+	BlockerQueue prometheus.Gauge
+
+	// BlockerFailures number of failed flushes of the blocker queue
+	BlockerFailures prometheus.Counter
+
+	// RepeaterDuration duration of runs of the repeater tasks
+	RepeaterDuration *prometheus.HistogramVec
+
+	// QueryDuration duration of db queries by methods of repositories
+	QueryDuration *prometheus.HistogramVec
+}
+
+func NewMetrics(appConfig *app.Config) *Metrics {
+	return &Metrics{
+		CheckTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "password_checks_total",
+			Help: "Number of checks of passwords",
+			ConstLabels: map[string]string{
+				"app": appConfig.Name,
+			},
+		}, []string{"outcome"}),
+		AddTotal: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "password_adds_total",
+			Help: "Number of additions of passwords",
+			ConstLabels: map[string]string{
+				"app": appConfig.Name,
+			},
+		}, []string{"outcome"}),
+		HashDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "hash_duration_seconds",
+			Help: "Duration of hashing",
+			ConstLabels: map[string]string{
+				"app": appConfig.Name,
+			},
+			// bcrypt takes tens of milliseconds, hmac takes microseconds
+			Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+		}, []string{"algorithm"}),
+		BlockerQueue: promauto.NewGauge(prometheus.GaugeOpts{
+			Name: "blocker_queue_length",
+			Help: "Number of passwords waiting for disabling",
+			ConstLabels: map[string]string{
+				"app": appConfig.Name,
+			},
+		}),
+		BlockerFailures: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "blocker_flush_failures_total",
+			Help: "Number of failed flushes of the blocker queue",
+			ConstLabels: map[string]string{
+				"app": appConfig.Name,
+			},
+		}),
+		RepeaterDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "repeater_duration_seconds",
+			Help: "Duration of runs of the repeater tasks",
+			ConstLabels: map[string]string{
+				"app": appConfig.Name,
+			},
+		}, []string{"task"}),
+		QueryDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "db_query_duration_seconds",
+			Help: "Duration of db queries",
+			ConstLabels: map[string]string{
+				"app": appConfig.Name,
+			},
+		}, []string{"method"}),
+	}
+}
+
+// Query start of the timer of the db query of the repository method, e.g. defer metrics.Query("Insert").ObserveDuration()
+func (metrics *Metrics) Query(method string) *prometheus.Timer {
+	return prometheus.NewTimer(metrics.QueryDuration.WithLabelValues(method))
+}
+
+// Hash start of the timer of hashing by the algorithm
+func (metrics *Metrics) Hash(algorithm string) *prometheus.Timer {
+	return prometheus.NewTimer(metrics.HashDuration.WithLabelValues(algorithm))
+}
+
+// Repeater start of the timer of the run of the repeater task
+func (metrics *Metrics) Repeater(task string) *prometheus.Timer {
+	return prometheus.NewTimer(metrics.RepeaterDuration.WithLabelValues(task))
+}
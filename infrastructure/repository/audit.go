@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type AuditRepository interface {
+	// InsertAudit appending of the entry, entries are never changed or deleted, uuid and time of the entry
+	// are set by the caller because they are covered by the hash
+	InsertAudit(ctx context.Context, entry *AuditEntry) (*AuditEntry, error)
+
+	// LastAudit return the last appended entry, db.RecordNotFoundError if the log is empty
+	LastAudit(ctx context.Context) (*AuditEntry, error)
+
+	// ChainAudit return up to limit entries appended after the entry with the id, in order of appending
+	ChainAudit(ctx context.Context, after int64, limit uint) ([]*AuditEntry, error)
+
+	// InsertCheckpoint saving of the signed checkpoint of the chain
+	InsertCheckpoint(ctx context.Context, checkpoint *AuditCheckpoint) (*AuditCheckpoint, error)
+
+	// LastCheckpoint return the last checkpoint, db.RecordNotFoundError if there are no checkpoints
+	LastCheckpoint(ctx context.Context) (*AuditCheckpoint, error)
+
+	// Checkpoints return all checkpoints in order of creation
+	Checkpoints(ctx context.Context) ([]*AuditCheckpoint, error)
+
+	// CountAudit count of entries of the login created in [from, to], nil bounds are not applied
+	CountAudit(ctx context.Context, login uuid.UUID, from *time.Time, to *time.Time) (int64, error)
+
+	// PageAudit page of entries of the login created in [from, to] in order of appending
+	PageAudit(ctx context.Context, login uuid.UUID, from *time.Time, to *time.Time, page uint, limit uint) ([]*AuditEntry, error)
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	infrastructureTime "github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const (
+	auditSqlTableName           = "audit"
+	auditCheckpointSqlTableName = "audit_checkpoints"
+)
+
+var (
+	auditColumns = []interface{}{
+		"id", "uuid", "login", "password", "action", "request_id", "address", "created_at", "previous_hash", "hash",
+	}
+
+	auditCheckpointColumns = []interface{}{"id", "audit_id", "hash", "signature", "created_at"}
+)
+
+type auditSql struct {
+	db      goqu.SQLDatabase
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
+}
+
+func NewAuditSql(db goqu.SQLDatabase, tracer trace.Tracer, metrics *metrics.Metrics) AuditRepository {
+	return &auditSql{db: db, tracer: tracer, metrics: metrics}
+}
+
+func (repository *auditSql) InsertAudit(ctx context.Context, entry *AuditEntry) (*AuditEntry, error) {
+	ctx, span := repository.tracer.Start(ctx, "InsertAudit")
+	defer span.End()
+	defer repository.metrics.Query("InsertAudit").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	sql, args, err := goqu.Insert(auditSqlTableName).Rows(entry).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = repository.db.ExecContext(ctx, sql, args...)
+
+	return entry, err
+}
+
+func (repository *auditSql) CountAudit(ctx context.Context, login uuid.UUID, from *time.Time, to *time.Time) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx, "CountAudit")
+	defer span.End()
+	defer repository.metrics.Query("CountAudit").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(auditSqlTableName).
+		Select(goqu.COUNT("uuid")).
+		Where(auditConditions(login, from, to)...).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	count := int64(0)
+	if err := repository.db.QueryRowContext(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repository *auditSql) PageAudit(ctx context.Context, login uuid.UUID, from *time.Time, to *time.Time, page uint, limit uint) ([]*AuditEntry, error) {
+	ctx, span := repository.tracer.Start(ctx, "PageAudit")
+	defer span.End()
+	defer repository.metrics.Query("PageAudit").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.Int("page", int(page)),
+		attribute.Int("limit", int(limit)),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(auditSqlTableName).
+		Select(auditColumns...).
+		Where(auditConditions(login, from, to)...).
+		Order(goqu.C("id").Asc()).
+		Offset(page * limit).
+		Limit(limit).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.find(ctx, sql, args...)
+}
+
+func (repository *auditSql) LastAudit(ctx context.Context) (*AuditEntry, error) {
+	ctx, span := repository.tracer.Start(ctx, "LastAudit")
+	defer span.End()
+	defer repository.metrics.Query("LastAudit").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	sql, args, err := goqu.From(auditSqlTableName).
+		Select(auditColumns...).
+		Order(goqu.C("id").Desc()).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := repository.find(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries[0], nil
+}
+
+func (repository *auditSql) ChainAudit(ctx context.Context, after int64, limit uint) ([]*AuditEntry, error) {
+	ctx, span := repository.tracer.Start(ctx, "ChainAudit")
+	defer span.End()
+	defer repository.metrics.Query("ChainAudit").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int64("after", after),
+		attribute.Int("limit", int(limit)),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(auditSqlTableName).
+		Select(auditColumns...).
+		Where(goqu.C("id").Gt(after)).
+		Order(goqu.C("id").Asc()).
+		Limit(limit).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.find(ctx, sql, args...)
+}
+
+func (repository *auditSql) find(ctx context.Context, sql string, args ...interface{}) ([]*AuditEntry, error) {
+	ctx, span := repository.tracer.Start(ctx, "find")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	rows, err := repository.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []*AuditEntry
+
+	for rows.Next() {
+		entry := &AuditEntry{}
+
+		err := rows.Scan(
+			&entry.Id,
+			&entry.Uuid,
+			&entry.Login,
+			&entry.Password,
+			&entry.Action,
+			&entry.RequestId,
+			&entry.Address,
+			&entry.CreatedAt,
+			&entry.PreviousHash,
+			&entry.Hash,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if len(entries) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return entries, nil
+}
+
+func (repository *auditSql) InsertCheckpoint(ctx context.Context, checkpoint *AuditCheckpoint) (*AuditCheckpoint, error) {
+	ctx, span := repository.tracer.Start(ctx, "InsertCheckpoint")
+	defer span.End()
+	defer repository.metrics.Query("InsertCheckpoint").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	now := infrastructureTime.NowUTC()
+	checkpoint.CreatedAt = &now
+
+	sql, args, err := goqu.Insert(auditCheckpointSqlTableName).Rows(checkpoint).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = repository.db.ExecContext(ctx, sql, args...)
+
+	return checkpoint, err
+}
+
+func (repository *auditSql) LastCheckpoint(ctx context.Context) (*AuditCheckpoint, error) {
+	ctx, span := repository.tracer.Start(ctx, "LastCheckpoint")
+	defer span.End()
+	defer repository.metrics.Query("LastCheckpoint").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	sql, args, err := goqu.From(auditCheckpointSqlTableName).
+		Select(auditCheckpointColumns...).
+		Order(goqu.C("id").Desc()).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	checkpoints, err := repository.findCheckpoints(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkpoints[0], nil
+}
+
+func (repository *auditSql) Checkpoints(ctx context.Context) ([]*AuditCheckpoint, error) {
+	ctx, span := repository.tracer.Start(ctx, "Checkpoints")
+	defer span.End()
+	defer repository.metrics.Query("Checkpoints").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	sql, args, err := goqu.From(auditCheckpointSqlTableName).
+		Select(auditCheckpointColumns...).
+		Order(goqu.C("id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.findCheckpoints(ctx, sql, args...)
+}
+
+func (repository *auditSql) findCheckpoints(ctx context.Context, sql string, args ...interface{}) ([]*AuditCheckpoint, error) {
+	ctx, span := repository.tracer.Start(ctx, "findCheckpoints")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	rows, err := repository.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var checkpoints []*AuditCheckpoint
+
+	for rows.Next() {
+		checkpoint := &AuditCheckpoint{}
+
+		if err := rows.Scan(&checkpoint.Id, &checkpoint.AuditId, &checkpoint.Hash, &checkpoint.Signature, &checkpoint.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	if len(checkpoints) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return checkpoints, nil
+}
+
+func auditConditions(login uuid.UUID, from *time.Time, to *time.Time) []goqu.Expression {
+	conditions := []goqu.Expression{goqu.Ex{"login": login}}
+
+	if from != nil {
+		conditions = append(conditions, goqu.C("created_at").Gte(from.UTC()))
+	}
+
+	if to != nil {
+		conditions = append(conditions, goqu.C("created_at").Lte(to.UTC()))
+	}
+
+	return conditions
+}
@@ -15,4 +15,92 @@ type Password struct {
 	CreatedAt  *time.Time `db:"created_at"`
 	UpdateAt   *time.Time `db:"update_at"`
 	ValidUntil *time.Time `db:"valid_until"`
+	Type       string     `db:"type"`
+	Scopes     string     `db:"scopes"`
+	Label      string     `db:"label"`
+	MaxUses    int64      `db:"max_uses"`
+	UseCount   int64      `db:"use_count"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	ValidFrom  *time.Time `db:"valid_from"`
+	MustChange bool       `db:"must_change"`
+
+	// ExpiryNotice threshold in days of the last sent expiry notification, 0 if nothing was sent
+	ExpiryNotice int64 `db:"expiry_notice"`
+}
+
+type Otp struct {
+	Id        int        `db:"-"`
+	Uuid      uuid.UUID  `db:"uuid"`
+	Login     uuid.UUID  `db:"login"`
+	Kind      string     `db:"kind"`
+	Secret    string     `db:"secret"`
+	Algorithm string     `db:"algorithm"`
+	Digits    int        `db:"digits"`
+	Period    int64      `db:"period"`
+	Counter   int64      `db:"counter"`
+	Disabled  bool       `db:"disabled"`
+	CreatedAt *time.Time `db:"created_at"`
+	UpdateAt  *time.Time `db:"update_at"`
+}
+
+type RecoveryCode struct {
+	Id        int        `db:"-"`
+	Uuid      uuid.UUID  `db:"uuid"`
+	Login     uuid.UUID  `db:"login"`
+	Batch     uuid.UUID  `db:"batch"`
+	Hash      string     `db:"hash"`
+	Used      bool       `db:"used"`
+	Disabled  bool       `db:"disabled"`
+	CreatedAt *time.Time `db:"created_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+type AuditEntry struct {
+	Id        int64      `db:"-"`
+	Uuid      uuid.UUID  `db:"uuid"`
+	Login     uuid.UUID  `db:"login"`
+	Password  uuid.UUID  `db:"password"`
+	Action    string     `db:"action"`
+	RequestId string     `db:"request_id"`
+	Address   string     `db:"address"`
+	CreatedAt *time.Time `db:"created_at"`
+
+	// PreviousHash hash of the previous entry of the chain, Hash covers it and fields of the entry
+	PreviousHash string `db:"previous_hash"`
+	Hash         string `db:"hash"`
+}
+
+type AuditCheckpoint struct {
+	Id        int        `db:"-"`
+	AuditId   int64      `db:"audit_id"`
+	Hash      string     `db:"hash"`
+	Signature string     `db:"signature"`
+	CreatedAt *time.Time `db:"created_at"`
+}
+
+// Webhook delivery of the event to one url, Payload is kept as sent so every attempt is signed over the same body
+type Webhook struct {
+	Id            int        `db:"-"`
+	Uuid          uuid.UUID  `db:"uuid"`
+	Event         uuid.UUID  `db:"event"`
+	Url           string     `db:"url"`
+	Payload       string     `db:"payload"`
+	Attempts      int        `db:"attempts"`
+	LastError     string     `db:"last_error"`
+	Dead          bool       `db:"dead"`
+	NextAttemptAt *time.Time `db:"next_attempt_at"`
+	DeliveredAt   *time.Time `db:"delivered_at"`
+	CreatedAt     *time.Time `db:"created_at"`
+}
+
+// OutboxEvent event of the credential change saved in the transaction of the change, Uuid is the idempotency key
+type OutboxEvent struct {
+	Id        int64      `db:"-"`
+	Uuid      uuid.UUID  `db:"uuid"`
+	Action    string     `db:"action"`
+	Login     uuid.UUID  `db:"login"`
+	Password  uuid.UUID  `db:"password"`
+	RequestId string     `db:"request_id"`
+	Payload   string     `db:"payload"`
+	CreatedAt *time.Time `db:"created_at"`
 }
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+type OtpRepository interface {
+	// FindActiveOtpByLogin return enrolled and not disabled secret of the login
+	FindActiveOtpByLogin(context.Context, uuid.UUID) (*Otp, error)
+
+	// InsertOtp saving of new secret, active secrets of the login are disabled in the same transaction
+	InsertOtp(context.Context, *Otp) (*Otp, error)
+
+	// DisableOtpByLogin disabling of all secrets of the login, return count of disabled secrets
+	DisableOtpByLogin(context.Context, uuid.UUID) (int64, error)
+
+	// UpdateOtpCounter setting counter of the secret only if it still equals to current, false is returned otherwise
+	UpdateOtpCounter(ctx context.Context, uuid uuid.UUID, current int64, counter int64) (bool, error)
+}
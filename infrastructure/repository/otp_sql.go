@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	otpSqlTableName = "otps"
+)
+
+type otpSql struct {
+	db      goqu.SQLDatabase
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
+}
+
+func NewOtpSql(db goqu.SQLDatabase, tracer trace.Tracer, metrics *metrics.Metrics) OtpRepository {
+	return &otpSql{db: db, tracer: tracer, metrics: metrics}
+}
+
+func (repository *otpSql) FindActiveOtpByLogin(ctx context.Context, login uuid.UUID) (*Otp, error) {
+	ctx, span := repository.tracer.Start(ctx, "FindActiveOtpByLogin")
+	defer span.End()
+	defer repository.metrics.Query("FindActiveOtpByLogin").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(otpSqlTableName).
+		Where(goqu.Ex{"login": login}, goqu.Ex{"disabled": false}).
+		Order(goqu.C("id").Desc()).
+		Limit(1).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repository.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		otp := &Otp{}
+
+		err := rows.Scan(
+			&otp.Id,
+			&otp.Uuid,
+			&otp.Login,
+			&otp.Kind,
+			&otp.Secret,
+			&otp.Algorithm,
+			&otp.Digits,
+			&otp.Period,
+			&otp.Counter,
+			&otp.Disabled,
+			&otp.CreatedAt,
+			&otp.UpdateAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return otp, nil
+	}
+
+	return nil, db.RecordNotFoundError
+}
+
+func (repository *otpSql) InsertOtp(ctx context.Context, otp *Otp) (*Otp, error) {
+	ctx, span := repository.tracer.Start(ctx, "InsertOtp")
+	defer span.End()
+	defer repository.metrics.Query("InsertOtp").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	now := time.NowUTC()
+	otp.CreatedAt = &now
+
+	disableSql, disableArgs, err := goqu.Update(otpSqlTableName).Set(
+		goqu.Record{"disabled": true, "update_at": now},
+	).Where(goqu.Ex{"login": otp.Login}, goqu.Ex{"disabled": false}).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	insertSql, insertArgs, err := goqu.Insert(otpSqlTableName).Rows(otp).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, disableSql, disableArgs...); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, insertSql, insertArgs...); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	return otp, tx.Commit()
+}
+
+func (repository *otpSql) DisableOtpByLogin(ctx context.Context, login uuid.UUID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx, "DisableOtpByLogin")
+	defer span.End()
+	defer repository.metrics.Query("DisableOtpByLogin").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Update(otpSqlTableName).Set(
+		goqu.Record{"disabled": true, "update_at": time.NowUTC()},
+	).Where(goqu.Ex{"login": login}, goqu.Ex{"disabled": false}).ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := repository.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (repository *otpSql) UpdateOtpCounter(ctx context.Context, uuid uuid.UUID, current int64, counter int64) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx, "UpdateOtpCounter")
+	defer span.End()
+	defer repository.metrics.Query("UpdateOtpCounter").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", uuid.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Update(otpSqlTableName).Set(
+		goqu.Record{"counter": counter, "update_at": time.NowUTC()},
+	).Where(goqu.Ex{"uuid": uuid}, goqu.Ex{"counter": current}, goqu.Ex{"disabled": false}).ToSQL()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := repository.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	countUpdate, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return countUpdate > 0, nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+)
+
+type OutboxRepository interface {
+	// Transaction running of fn in one transaction, repositories called with the ctx passed to fn join it,
+	// fn joins the transaction of the ctx if it is already started
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// AppendOutbox saving of events, in the transaction of the ctx if it is started
+	AppendOutbox(ctx context.Context, events ...*OutboxEvent) error
+
+	// NextOutbox return up to limit events saved after the event with the id, in order of saving
+	NextOutbox(ctx context.Context, after int64, limit uint) ([]*OutboxEvent, error)
+
+	// DeleteOutbox deleting of events with id not greater than the id
+	DeleteOutbox(ctx context.Context, id int64) error
+
+	// OutboxCursor return the id of the last event delivered to the sink, 0 if nothing is delivered
+	OutboxCursor(ctx context.Context, sink string) (int64, error)
+
+	// SaveOutboxCursor saving of the id of the last event delivered to the sink
+	SaveOutboxCursor(ctx context.Context, sink string, id int64) error
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	goSql "database/sql"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	outboxSqlTableName       = "outbox"
+	outboxCursorSqlTableName = "outbox_cursors"
+)
+
+var (
+	outboxColumns = []interface{}{"id", "uuid", "action", "login", "password", "request_id", "payload", "created_at"}
+)
+
+type outboxSql struct {
+	db      goqu.SQLDatabase
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
+}
+
+func NewOutboxSql(db goqu.SQLDatabase, tracer trace.Tracer, metrics *metrics.Metrics) OutboxRepository {
+	return &outboxSql{db: db, tracer: tracer, metrics: metrics}
+}
+
+func (repository *outboxSql) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, span := repository.tracer.Start(ctx, "Transaction")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	return transaction(ctx, repository.db, fn)
+}
+
+func (repository *outboxSql) AppendOutbox(ctx context.Context, events ...*OutboxEvent) error {
+	ctx, span := repository.tracer.Start(ctx, "AppendOutbox")
+	defer span.End()
+	defer repository.metrics.Query("AppendOutbox").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int("count", len(events)),
+		attribute.String("repository", "sql"),
+	)
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.NowUTC()
+
+	rows := make([]interface{}, len(events))
+	for index, event := range events {
+		if event.Uuid == uuid.Nil {
+			event.Uuid = uuid.New()
+		}
+
+		if event.CreatedAt == nil {
+			event.CreatedAt = &now
+		}
+
+		rows[index] = event
+	}
+
+	sql, args, err := goqu.Insert(outboxSqlTableName).Rows(rows...).ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+
+	return err
+}
+
+func (repository *outboxSql) NextOutbox(ctx context.Context, after int64, limit uint) ([]*OutboxEvent, error) {
+	ctx, span := repository.tracer.Start(ctx, "NextOutbox")
+	defer span.End()
+	defer repository.metrics.Query("NextOutbox").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int64("after", after),
+		attribute.Int("limit", int(limit)),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(outboxSqlTableName).
+		Select(outboxColumns...).
+		Where(goqu.C("id").Gt(after)).
+		Order(goqu.C("id").Asc()).
+		Limit(limit).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := executorOf(ctx, repository.db).QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []*OutboxEvent
+
+	for rows.Next() {
+		event := &OutboxEvent{}
+
+		err := rows.Scan(
+			&event.Id,
+			&event.Uuid,
+			&event.Action,
+			&event.Login,
+			&event.Password,
+			&event.RequestId,
+			&event.Payload,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return events, nil
+}
+
+func (repository *outboxSql) DeleteOutbox(ctx context.Context, id int64) error {
+	ctx, span := repository.tracer.Start(ctx, "DeleteOutbox")
+	defer span.End()
+	defer repository.metrics.Query("DeleteOutbox").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int64("id", id),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Delete(outboxSqlTableName).Where(goqu.C("id").Lte(id)).ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+
+	return err
+}
+
+func (repository *outboxSql) OutboxCursor(ctx context.Context, sink string) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx, "OutboxCursor")
+	defer span.End()
+	defer repository.metrics.Query("OutboxCursor").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("sink", sink),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(outboxCursorSqlTableName).
+		Select("outbox_id").
+		Where(goqu.Ex{"sink": sink}).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	id := int64(0)
+
+	err = executorOf(ctx, repository.db).QueryRowContext(ctx, sql, args...).Scan(&id)
+	if err == goSql.ErrNoRows {
+		return 0, nil
+	}
+
+	return id, err
+}
+
+func (repository *outboxSql) SaveOutboxCursor(ctx context.Context, sink string, id int64) error {
+	ctx, span := repository.tracer.Start(ctx, "SaveOutboxCursor")
+	defer span.End()
+	defer repository.metrics.Query("SaveOutboxCursor").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("sink", sink),
+		attribute.Int64("id", id),
+		attribute.String("repository", "sql"),
+	)
+
+	now := time.NowUTC()
+
+	sql, args, err := goqu.Insert(outboxCursorSqlTableName).
+		Rows(goqu.Record{"sink": sink, "outbox_id": id, "update_at": now}).
+		OnConflict(goqu.DoUpdate("sink", goqu.Record{"outbox_id": id, "update_at": now})).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+
+	return err
+}
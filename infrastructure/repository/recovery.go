@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+)
+
+// RecoveryRepository codes are changed in the transaction of the ctx if it is started
+type RecoveryRepository interface {
+	// ReplaceRecoveryCodes saving of new batch of codes of the login, not used codes of previous batches
+	// are disabled in the same transaction
+	ReplaceRecoveryCodes(ctx context.Context, login uuid.UUID, codes ...*RecoveryCode) ([]*RecoveryCode, error)
+
+	// UseRecoveryCode marking of the active code with the hash as used, false is returned if there is no such code
+	UseRecoveryCode(ctx context.Context, login uuid.UUID, hash string) (bool, error)
+
+	// CountRecoveryCodes return count of active codes of the login
+	CountRecoveryCodes(ctx context.Context, login uuid.UUID) (int64, error)
+
+	// DisableRecoveryCodes disabling of all active codes of the login, return count of disabled codes
+	DisableRecoveryCodes(ctx context.Context, login uuid.UUID) (int64, error)
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	recoverySqlTableName = "recovery_codes"
+)
+
+type recoverySql struct {
+	db      goqu.SQLDatabase
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
+}
+
+func NewRecoverySql(db goqu.SQLDatabase, tracer trace.Tracer, metrics *metrics.Metrics) RecoveryRepository {
+	return &recoverySql{db: db, tracer: tracer, metrics: metrics}
+}
+
+func (repository *recoverySql) ReplaceRecoveryCodes(ctx context.Context, login uuid.UUID, codes ...*RecoveryCode) ([]*RecoveryCode, error) {
+	ctx, span := repository.tracer.Start(ctx, "ReplaceRecoveryCodes")
+	defer span.End()
+	defer repository.metrics.Query("ReplaceRecoveryCodes").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.Int("count", len(codes)),
+		attribute.String("repository", "sql"),
+	)
+
+	now := time.NowUTC()
+	batch := uuid.New()
+
+	rows := make([]interface{}, len(codes))
+	for index, code := range codes {
+		code.Uuid = uuid.New()
+		code.Login = login
+		code.Batch = batch
+		code.CreatedAt = &now
+
+		rows[index] = code
+	}
+
+	disableSql, disableArgs, err := repository.disableSql(login)
+	if err != nil {
+		return nil, err
+	}
+
+	insertSql, insertArgs, err := goqu.Insert(recoverySqlTableName).Rows(rows...).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	err = transaction(ctx, repository.db, func(ctx context.Context) error {
+		executor := executorOf(ctx, repository.db)
+
+		if _, err := executor.ExecContext(ctx, disableSql, disableArgs...); err != nil {
+			return err
+		}
+
+		_, err := executor.ExecContext(ctx, insertSql, insertArgs...)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (repository *recoverySql) UseRecoveryCode(ctx context.Context, login uuid.UUID, hash string) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx, "UseRecoveryCode")
+	defer span.End()
+	defer repository.metrics.Query("UseRecoveryCode").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Update(recoverySqlTableName).Set(
+		goqu.Record{"used": true, "used_at": time.NowUTC()},
+	).Where(
+		goqu.Ex{"login": login},
+		goqu.Ex{"hash": hash},
+		goqu.Ex{"used": false},
+		goqu.Ex{"disabled": false},
+	).ToSQL()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	countUpdate, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return countUpdate > 0, nil
+}
+
+func (repository *recoverySql) CountRecoveryCodes(ctx context.Context, login uuid.UUID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx, "CountRecoveryCodes")
+	defer span.End()
+	defer repository.metrics.Query("CountRecoveryCodes").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(recoverySqlTableName).
+		Select(goqu.COUNT("uuid")).
+		Where(goqu.Ex{"login": login}, goqu.Ex{"used": false}, goqu.Ex{"disabled": false}).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	count := int64(0)
+	if err := repository.db.QueryRowContext(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repository *recoverySql) DisableRecoveryCodes(ctx context.Context, login uuid.UUID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx, "DisableRecoveryCodes")
+	defer span.End()
+	defer repository.metrics.Query("DisableRecoveryCodes").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := repository.disableSql(login)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (repository *recoverySql) disableSql(login uuid.UUID) (string, []interface{}, error) {
+	return goqu.Update(recoverySqlTableName).Set(
+		goqu.Record{"disabled": true},
+	).Where(goqu.Ex{"login": login}, goqu.Ex{"used": false}, goqu.Ex{"disabled": false}).ToSQL()
+}
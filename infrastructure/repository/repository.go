@@ -2,20 +2,47 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ConflictError = errors.New("record was changed concurrently")
 )
 
 type Finder interface {
+	FindByUuid(context.Context, uuid.UUID) (*Password, error)
+	FindByUuids(context.Context, ...uuid.UUID) ([]*Password, error)
 	FindByLogin(context.Context, uuid.UUID) ([]*Password, error)
 	FindActiveByLogin(context.Context, uuid.UUID) ([]*Password, error)
 }
 
 type Saver interface {
 	Insert(context.Context, *Password) (*Password, error)
+	InsertMany(context.Context, ...*Password) ([]*Password, error)
+
+	// LockLogin locking of passwords of the login until the end of the transaction of the ctx,
+	// concurrent locking of the login waits for it, so passwords read after locking stay actual
+	LockLogin(ctx context.Context, login uuid.UUID) error
+
+	// Redeem disabling of the active token, all active passwords of the login and inserting of the password
+	// in the transaction of the ctx or in a new one, db.RecordNotFoundError is returned if the token is already disabled
+	Redeem(ctx context.Context, token uuid.UUID, password *Password) (*Password, error)
+}
+
+type Updater interface {
+	Update(context.Context, *Password) (*Password, error)
+
+	// Use counting of the usage of the active password, the password is disabled in the same statement
+	// when it is one-time, must be changed or its limit of uses is reached, false if the password is already used up
+	Use(context.Context, uuid.UUID) (bool, error)
 }
 
 type Blocker interface {
+	// DisableByUuids disabling of the active passwords, db.RecordNotFoundError is returned if none of them was active
 	DisableByUuids(context.Context, ...uuid.UUID) (bool, error)
+	DisableByLogin(ctx context.Context, login uuid.UUID, except ...uuid.UUID) (int64, error)
 }
 
 type Paginator interface {
@@ -23,9 +50,32 @@ type Paginator interface {
 	Page(ctx context.Context, page uint, limit uint, login uuid.UUID) ([]*Password, error)
 }
 
+type Expirer interface {
+	// CountExpiring count of active passwords of all logins which validity ends not later than until
+	CountExpiring(ctx context.Context, until time.Time) (int64, error)
+
+	// Expiring page of active passwords of all logins which validity ends not later than until, soonest first
+	Expiring(ctx context.Context, until time.Time, page uint, limit uint) ([]*Password, error)
+
+	// Unnotified active passwords which validity ends not later than until and the notification
+	// about the notice threshold or a closer one has not been sent yet
+	Unnotified(ctx context.Context, until time.Time, notice int64, limit uint) ([]*Password, error)
+
+	// Notice changing of the threshold of the last notification only if it still equals to current
+	Notice(ctx context.Context, uuid uuid.UUID, current int64, notice int64) (bool, error)
+}
+
+type Pinger interface {
+	// Ping checking that the db is reachable and answers queries
+	Ping(context.Context) error
+}
+
 type Repository interface {
+	Pinger
 	Finder
 	Saver
+	Updater
 	Blocker
 	Paginator
+	Expirer
 }
@@ -2,12 +2,15 @@ package repository
 
 import (
 	"context"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/metrics"
 	"github.com/Diez37/passwords/infrastructure/time"
 	"github.com/diez37/go-packages/clients/db"
 	"github.com/doug-martin/goqu/v9"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
+	goTime "time"
 )
 
 const (
@@ -15,17 +18,31 @@ const (
 )
 
 type sql struct {
-	db     goqu.SQLDatabase
-	tracer trace.Tracer
+	db      goqu.SQLDatabase
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
 }
 
-func NewSql(db goqu.SQLDatabase, tracer trace.Tracer) Repository {
-	return &sql{db: db, tracer: tracer}
+func NewSql(db goqu.SQLDatabase, tracer trace.Tracer, metrics *metrics.Metrics) Repository {
+	return &sql{db: db, tracer: tracer, metrics: metrics}
+}
+
+func (repository *sql) Ping(ctx context.Context) error {
+	ctx, span := repository.tracer.Start(ctx, "Ping")
+	defer span.End()
+	defer repository.metrics.Query("Ping").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	one := 0
+
+	return repository.db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
 }
 
 func (repository *sql) Count(ctx context.Context) (int64, error) {
 	ctx, span := repository.tracer.Start(ctx, "Count")
 	defer span.End()
+	defer repository.metrics.Query("Count").ObserveDuration()
 
 	span.SetAttributes(
 		attribute.String("repository", "sql"),
@@ -36,7 +53,7 @@ func (repository *sql) Count(ctx context.Context) (int64, error) {
 		return 0, err
 	}
 
-	rows, err := repository.db.QueryContext(ctx, sql)
+	rows, err := executorOf(ctx, repository.db).QueryContext(ctx, sql)
 	if err != nil {
 		return 0, err
 	}
@@ -57,6 +74,7 @@ func (repository *sql) Count(ctx context.Context) (int64, error) {
 func (repository *sql) Page(ctx context.Context, page uint, limit uint, login uuid.UUID) ([]*Password, error) {
 	ctx, span := repository.tracer.Start(ctx, "Page")
 	defer span.End()
+	defer repository.metrics.Query("Page").ObserveDuration()
 
 	span.SetAttributes(
 		attribute.Int("page", int(page)),
@@ -73,46 +91,54 @@ func (repository *sql) Page(ctx context.Context, page uint, limit uint, login uu
 		return nil, err
 	}
 
-	rows, err := repository.db.QueryContext(ctx, sql, args...)
+	return repository.find(ctx, sql, args...)
+}
+
+func (repository *sql) FindByUuid(ctx context.Context, uuid uuid.UUID) (*Password, error) {
+	ctx, span := repository.tracer.Start(ctx, "FindByUuid")
+	defer span.End()
+	defer repository.metrics.Query("FindByUuid").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", uuid.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(sqlTableName).Where(goqu.Ex{"uuid": uuid}).ToSQL()
 	if err != nil {
 		return nil, err
 	}
 
-	defer rows.Close()
+	passwords, err := repository.find(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
 
-	var passwords []*Password
+	return passwords[0], nil
+}
 
-	for rows.Next() {
-		password := &Password{}
-
-		err := rows.Scan(
-			&password.Id,
-			&password.Uuid,
-			&password.Login,
-			&password.Password,
-			&password.Disabled,
-			&password.OneTime,
-			&password.CreatedAt,
-			&password.UpdateAt,
-			&password.ValidUntil,
-		)
-		if err != nil {
-			return nil, err
-		}
+func (repository *sql) FindByUuids(ctx context.Context, uuids ...uuid.UUID) ([]*Password, error) {
+	ctx, span := repository.tracer.Start(ctx, "FindByUuids")
+	defer span.End()
+	defer repository.metrics.Query("FindByUuids").ObserveDuration()
 
-		passwords = append(passwords, password)
-	}
+	span.SetAttributes(
+		attribute.Int("count", len(uuids)),
+		attribute.String("repository", "sql"),
+	)
 
-	if len(passwords) == 0 {
-		return nil, db.RecordNotFoundError
+	sql, args, err := goqu.From(sqlTableName).Where(goqu.Ex{"uuid": uuids}).ToSQL()
+	if err != nil {
+		return nil, err
 	}
 
-	return passwords, nil
+	return repository.find(ctx, sql, args...)
 }
 
 func (repository *sql) FindByLogin(ctx context.Context, login uuid.UUID) ([]*Password, error) {
 	ctx, span := repository.tracer.Start(ctx, "FindByLogin")
 	defer span.End()
+	defer repository.metrics.Query("FindByLogin").ObserveDuration()
 
 	span.SetAttributes(
 		attribute.String("login", login.String()),
@@ -130,6 +156,7 @@ func (repository *sql) FindByLogin(ctx context.Context, login uuid.UUID) ([]*Pas
 func (repository *sql) FindActiveByLogin(ctx context.Context, login uuid.UUID) ([]*Password, error) {
 	ctx, span := repository.tracer.Start(ctx, "FindActiveByLogin")
 	defer span.End()
+	defer repository.metrics.Query("FindActiveByLogin").ObserveDuration()
 
 	span.SetAttributes(
 		attribute.String("login", login.String()),
@@ -150,7 +177,7 @@ func (repository *sql) find(ctx context.Context, sql string, args ...interface{}
 
 	span.SetAttributes(attribute.String("repository", "sql"))
 
-	rows, err := repository.db.QueryContext(ctx, sql, args...)
+	rows, err := executorOf(ctx, repository.db).QueryContext(ctx, sql, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -160,19 +187,7 @@ func (repository *sql) find(ctx context.Context, sql string, args ...interface{}
 	var passwords []*Password
 
 	for rows.Next() {
-		password := &Password{}
-
-		err := rows.Scan(
-			&password.Id,
-			&password.Uuid,
-			&password.Login,
-			&password.Password,
-			&password.Disabled,
-			&password.OneTime,
-			&password.CreatedAt,
-			&password.UpdateAt,
-			&password.ValidUntil,
-		)
+		password, err := scanPassword(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -190,6 +205,7 @@ func (repository *sql) find(ctx context.Context, sql string, args ...interface{}
 func (repository *sql) Insert(ctx context.Context, password *Password) (*Password, error) {
 	ctx, span := repository.tracer.Start(ctx, "Insert")
 	defer span.End()
+	defer repository.metrics.Query("Insert").ObserveDuration()
 
 	span.SetAttributes(attribute.String("repository", "sql"))
 
@@ -204,26 +220,242 @@ func (repository *sql) Insert(ctx context.Context, password *Password) (*Passwor
 		return nil, err
 	}
 
-	_, err = repository.db.ExecContext(ctx, sql, args...)
+	_, err = executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
 
 	return password, err
 }
 
+// Update changing validity, one-time flag and disabled flag of the password, the record is updated only
+// if its update_at still equals to password.UpdateAt, otherwise ConflictError is returned
+func (repository *sql) Update(ctx context.Context, password *Password) (*Password, error) {
+	ctx, span := repository.tracer.Start(ctx, "Update")
+	defer span.End()
+	defer repository.metrics.Query("Update").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", password.Uuid.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	version := goqu.Ex{"update_at": nil}
+	if password.UpdateAt != nil {
+		version = goqu.Ex{"update_at": *password.UpdateAt}
+	}
+
+	now := time.NowUTC()
+
+	sql, args, err := goqu.Update(sqlTableName).Set(
+		goqu.Record{
+			"valid_until":   password.ValidUntil,
+			"one_time":      password.OneTime,
+			"disabled":      password.Disabled,
+			"update_at":     now,
+			"expiry_notice": 0,
+		},
+	).Where(goqu.Ex{"uuid": password.Uuid}, version).ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if countUpdate, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if countUpdate == 0 {
+		if _, err := repository.FindByUuid(ctx, password.Uuid); err != nil {
+			return nil, err
+		}
+
+		return nil, ConflictError
+	}
+
+	return repository.FindByUuid(ctx, password.Uuid)
+}
+
+func (repository *sql) Use(ctx context.Context, uuid uuid.UUID) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx, "Use")
+	defer span.End()
+	defer repository.metrics.Query("Use").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", uuid.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	now := time.NowUTC()
+
+	// update_at is changed only with disabling, so a version of the password is not changed by every check
+	usedUp := "one_time OR must_change OR (max_uses > 0 AND use_count + 1 >= max_uses)"
+
+	sql, args, err := goqu.Update(sqlTableName).Set(
+		goqu.Record{
+			"use_count":    goqu.L("use_count + 1"),
+			"last_used_at": now,
+			"disabled":     goqu.L("CASE WHEN "+usedUp+" THEN ? ELSE disabled END", true),
+			"update_at":    goqu.L("CASE WHEN "+usedUp+" THEN ? ELSE update_at END", now),
+		},
+	).Where(
+		goqu.Ex{"uuid": uuid},
+		goqu.Ex{"disabled": false},
+		goqu.Or(goqu.Ex{"max_uses": 0}, goqu.C("use_count").Lt(goqu.I("max_uses"))),
+	).ToSQL()
+
+	if err != nil {
+		return false, err
+	}
+
+	result, err := executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	countUpdate, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return countUpdate > 0, nil
+}
+
+func (repository *sql) InsertMany(ctx context.Context, passwords ...*Password) ([]*Password, error) {
+	ctx, span := repository.tracer.Start(ctx, "InsertMany")
+	defer span.End()
+	defer repository.metrics.Query("InsertMany").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int("count", len(passwords)),
+		attribute.String("repository", "sql"),
+	)
+
+	now := time.NowUTC()
+
+	rows := make([]interface{}, len(passwords))
+	for index, password := range passwords {
+		password.Uuid = uuid.New()
+		password.CreatedAt = &now
+
+		rows[index] = password
+	}
+
+	sql, args, err := goqu.Insert(sqlTableName).Rows(rows...).ToSQL()
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+
+	return passwords, err
+}
+
+// LockLogin locking by the update of rows of the login without changes, it is portable across drivers:
+// the row locks are held until the end of the transaction and sqlite takes the write lock of the db
+func (repository *sql) LockLogin(ctx context.Context, login uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx, "LockLogin")
+	defer span.End()
+	defer repository.metrics.Query("LockLogin").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Update(sqlTableName).Set(
+		goqu.Record{"login": goqu.I("login")},
+	).Where(goqu.Ex{"login": login}).ToSQL()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+
+	return err
+}
+
+func (repository *sql) Redeem(ctx context.Context, token uuid.UUID, password *Password) (*Password, error) {
+	ctx, span := repository.tracer.Start(ctx, "Redeem")
+	defer span.End()
+	defer repository.metrics.Query("Redeem").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", token.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	now := time.NowUTC()
+
+	password.Uuid = uuid.New()
+	password.CreatedAt = &now
+
+	tokenSql, tokenArgs, err := goqu.Update(sqlTableName).Set(
+		goqu.Record{"disabled": true, "update_at": now},
+	).Where(goqu.Ex{"uuid": token}, goqu.Ex{"disabled": false}).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	disableSql, disableArgs, err := goqu.Update(sqlTableName).Set(
+		goqu.Record{"disabled": true, "update_at": now},
+	).Where(goqu.Ex{"login": password.Login}, goqu.Ex{"disabled": false}).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	insertSql, insertArgs, err := goqu.Insert(sqlTableName).Rows(password).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	err = transaction(ctx, repository.db, func(ctx context.Context) error {
+		executor := executorOf(ctx, repository.db)
+
+		result, err := executor.ExecContext(ctx, tokenSql, tokenArgs...)
+		if err != nil {
+			return err
+		}
+
+		if countUpdate, err := result.RowsAffected(); err != nil {
+			return err
+		} else if countUpdate == 0 {
+			return db.RecordNotFoundError
+		}
+
+		if _, err := executor.ExecContext(ctx, disableSql, disableArgs...); err != nil {
+			return err
+		}
+
+		_, err = executor.ExecContext(ctx, insertSql, insertArgs...)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return password, nil
+}
+
 func (repository *sql) DisableByUuids(ctx context.Context, uuids ...uuid.UUID) (bool, error) {
 	ctx, span := repository.tracer.Start(ctx, "DisableByUuids")
 	defer span.End()
+	defer repository.metrics.Query("DisableByUuids").ObserveDuration()
 
 	span.SetAttributes(attribute.String("repository", "sql"))
 
 	sql, args, err := goqu.Update(sqlTableName).Set(
 		goqu.Record{"disabled": true, "update_at": time.NowUTC()},
-	).Where(goqu.Ex{"uuid": uuids}).ToSQL()
+	).Where(goqu.Ex{"uuid": uuids}, goqu.Ex{"disabled": false}).ToSQL()
 
 	if err != nil {
 		return false, err
 	}
 
-	result, err := repository.db.ExecContext(ctx, sql, args...)
+	result, err := executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
 	if err != nil {
 		return false, err
 	}
@@ -236,3 +468,183 @@ func (repository *sql) DisableByUuids(ctx context.Context, uuids ...uuid.UUID) (
 
 	return true, nil
 }
+
+// DisableByLogin disabling all active passwords of the login in one statement except passed uuids,
+// return count of disabled passwords
+func (repository *sql) DisableByLogin(ctx context.Context, login uuid.UUID, except ...uuid.UUID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx, "DisableByLogin")
+	defer span.End()
+	defer repository.metrics.Query("DisableByLogin").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("login", login.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	conditions := []goqu.Expression{goqu.Ex{"login": login}, goqu.Ex{"disabled": false}}
+	if len(except) > 0 {
+		conditions = append(conditions, goqu.C("uuid").NotIn(except))
+	}
+
+	sql, args, err := goqu.Update(sqlTableName).Set(
+		goqu.Record{"disabled": true, "update_at": time.NowUTC()},
+	).Where(conditions...).ToSQL()
+
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (repository *sql) CountExpiring(ctx context.Context, until goTime.Time) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx, "CountExpiring")
+	defer span.End()
+	defer repository.metrics.Query("CountExpiring").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	sql, args, err := goqu.From(sqlTableName).
+		Select(goqu.COUNT("uuid")).
+		Where(expiring(until)...).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	count := int64(0)
+	if err := executorOf(ctx, repository.db).QueryRowContext(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repository *sql) Expiring(ctx context.Context, until goTime.Time, page uint, limit uint) ([]*Password, error) {
+	ctx, span := repository.tracer.Start(ctx, "Expiring")
+	defer span.End()
+	defer repository.metrics.Query("Expiring").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int("page", int(page)),
+		attribute.Int("limit", int(limit)),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(sqlTableName).
+		Where(expiring(until)...).
+		Order(goqu.C("valid_until").Asc(), goqu.C("id").Asc()).
+		Offset(page * limit).
+		Limit(limit).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.find(ctx, sql, args...)
+}
+
+func (repository *sql) Unnotified(ctx context.Context, until goTime.Time, notice int64, limit uint) ([]*Password, error) {
+	ctx, span := repository.tracer.Start(ctx, "Unnotified")
+	defer span.End()
+	defer repository.metrics.Query("Unnotified").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int64("notice", notice),
+		attribute.String("repository", "sql"),
+	)
+
+	conditions := append(
+		expiring(until),
+		goqu.Or(goqu.Ex{"expiry_notice": 0}, goqu.C("expiry_notice").Gt(notice)),
+	)
+
+	sql, args, err := goqu.From(sqlTableName).
+		Where(conditions...).
+		Order(goqu.C("valid_until").Asc(), goqu.C("id").Asc()).
+		Limit(limit).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.find(ctx, sql, args...)
+}
+
+func (repository *sql) Notice(ctx context.Context, uuid uuid.UUID, current int64, notice int64) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx, "Notice")
+	defer span.End()
+	defer repository.metrics.Query("Notice").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", uuid.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Update(sqlTableName).Set(
+		goqu.Record{"expiry_notice": notice},
+	).Where(goqu.Ex{"uuid": uuid}, goqu.Ex{"expiry_notice": current}).ToSQL()
+
+	if err != nil {
+		return false, err
+	}
+
+	result, err := executorOf(ctx, repository.db).ExecContext(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	countUpdate, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return countUpdate > 0, nil
+}
+
+// expiring conditions of active passwords which validity ends after now and not later than until,
+// reset tokens are not included
+func expiring(until goTime.Time) []goqu.Expression {
+	return []goqu.Expression{
+		goqu.Ex{"disabled": false},
+		goqu.C("type").Neq(string(domain.ResetType)),
+		goqu.C("valid_until").Gt(time.NowUTC()),
+		goqu.C("valid_until").Lte(until),
+	}
+}
+
+// scanPassword reading of the password from the row, columns are in order of the passwords table
+func scanPassword(row interface{ Scan(...interface{}) error }) (*Password, error) {
+	password := &Password{}
+
+	err := row.Scan(
+		&password.Id,
+		&password.Uuid,
+		&password.Login,
+		&password.Password,
+		&password.Disabled,
+		&password.OneTime,
+		&password.CreatedAt,
+		&password.UpdateAt,
+		&password.ValidUntil,
+		&password.Type,
+		&password.Scopes,
+		&password.Label,
+		&password.MaxUses,
+		&password.UseCount,
+		&password.LastUsedAt,
+		&password.ValidFrom,
+		&password.MustChange,
+		&password.ExpiryNotice,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return password, nil
+}
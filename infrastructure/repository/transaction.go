@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	goSql "database/sql"
+	"github.com/doug-martin/goqu/v9"
+)
+
+type transactionKey struct{}
+
+// executor statements of the db or of the transaction
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (goSql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*goSql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *goSql.Row
+}
+
+// transaction running of fn in the transaction of the ctx or in a new one, the new one is committed
+// if fn is succeeded and is rolled back otherwise
+func transaction(ctx context.Context, db goqu.SQLDatabase, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(transactionKey{}).(*goSql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(context.WithValue(ctx, transactionKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// executorOf return the transaction of the ctx if it is started by transaction, the db otherwise
+func executorOf(ctx context.Context, db goqu.SQLDatabase) executor {
+	if tx, ok := ctx.Value(transactionKey{}).(*goSql.Tx); ok {
+		return tx
+	}
+
+	return db
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"time"
+)
+
+type WebhookRepository interface {
+	// InsertWebhooks saving of deliveries to the outbox, deliveries of already saved events to the same urls are skipped
+	InsertWebhooks(ctx context.Context, webhooks ...*Webhook) ([]*Webhook, error)
+
+	// DueWebhooks return up to limit not delivered and not dead deliveries with the next attempt not later than now
+	DueWebhooks(ctx context.Context, now time.Time, limit uint) ([]*Webhook, error)
+
+	// DeliverWebhook marking of the delivery as delivered
+	DeliverWebhook(ctx context.Context, uuid uuid.UUID) error
+
+	// FailWebhook saving of the failed attempt of the delivery: attempts, last error, next attempt and dead flag
+	FailWebhook(ctx context.Context, webhook *Webhook) error
+
+	// CountDeadWebhooks count of deliveries moved to the dead-letter list
+	CountDeadWebhooks(ctx context.Context) (int64, error)
+
+	// PageDeadWebhooks page of deliveries moved to the dead-letter list in order of creation
+	PageDeadWebhooks(ctx context.Context, page uint, limit uint) ([]*Webhook, error)
+
+	// RequeueWebhook return of the dead delivery to the outbox with reset attempts,
+	// false if there is no dead delivery with the uuid
+	RequeueWebhook(ctx context.Context, uuid uuid.UUID, now time.Time) (bool, error)
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"github.com/Diez37/passwords/infrastructure/metrics"
+	infrastructureTime "github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const (
+	webhookSqlTableName = "webhooks"
+)
+
+var (
+	webhookColumns = []interface{}{
+		"id", "uuid", "event", "url", "payload", "attempts", "last_error", "dead", "next_attempt_at", "delivered_at", "created_at",
+	}
+)
+
+type webhookSql struct {
+	db      goqu.SQLDatabase
+	tracer  trace.Tracer
+	metrics *metrics.Metrics
+}
+
+func NewWebhookSql(db goqu.SQLDatabase, tracer trace.Tracer, metrics *metrics.Metrics) WebhookRepository {
+	return &webhookSql{db: db, tracer: tracer, metrics: metrics}
+}
+
+func (repository *webhookSql) InsertWebhooks(ctx context.Context, webhooks ...*Webhook) ([]*Webhook, error) {
+	ctx, span := repository.tracer.Start(ctx, "InsertWebhooks")
+	defer span.End()
+	defer repository.metrics.Query("InsertWebhooks").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int("count", len(webhooks)),
+		attribute.String("repository", "sql"),
+	)
+
+	if len(webhooks) == 0 {
+		return webhooks, nil
+	}
+
+	now := infrastructureTime.NowUTC()
+
+	rows := make([]interface{}, len(webhooks))
+	for index, webhook := range webhooks {
+		webhook.Uuid = uuid.New()
+		webhook.CreatedAt = &now
+
+		if webhook.NextAttemptAt == nil {
+			webhook.NextAttemptAt = &now
+		}
+
+		rows[index] = webhook
+	}
+
+	// the event may be relayed again after a failure, the delivery of it to the url is saved once
+	sql, args, err := goqu.Insert(webhookSqlTableName).Rows(rows...).OnConflict(goqu.DoNothing()).ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := repository.db.ExecContext(ctx, sql, args...); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (repository *webhookSql) DueWebhooks(ctx context.Context, now time.Time, limit uint) ([]*Webhook, error) {
+	ctx, span := repository.tracer.Start(ctx, "DueWebhooks")
+	defer span.End()
+	defer repository.metrics.Query("DueWebhooks").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int("limit", int(limit)),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(webhookSqlTableName).
+		Select(webhookColumns...).
+		Where(
+			goqu.C("delivered_at").IsNull(),
+			goqu.Ex{"dead": false},
+			goqu.C("next_attempt_at").Lte(now.UTC()),
+		).
+		Order(goqu.C("id").Asc()).
+		Limit(limit).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.find(ctx, sql, args...)
+}
+
+func (repository *webhookSql) DeliverWebhook(ctx context.Context, uuid uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx, "DeliverWebhook")
+	defer span.End()
+	defer repository.metrics.Query("DeliverWebhook").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", uuid.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Update(webhookSqlTableName).
+		Set(goqu.Record{"delivered_at": infrastructureTime.NowUTC()}).
+		Where(goqu.Ex{"uuid": uuid}).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.db.ExecContext(ctx, sql, args...)
+
+	return err
+}
+
+func (repository *webhookSql) FailWebhook(ctx context.Context, webhook *Webhook) error {
+	ctx, span := repository.tracer.Start(ctx, "FailWebhook")
+	defer span.End()
+	defer repository.metrics.Query("FailWebhook").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", webhook.Uuid.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Update(webhookSqlTableName).
+		Set(goqu.Record{
+			"attempts":        webhook.Attempts,
+			"last_error":      webhook.LastError,
+			"dead":            webhook.Dead,
+			"next_attempt_at": webhook.NextAttemptAt,
+		}).
+		Where(goqu.Ex{"uuid": webhook.Uuid}).
+		ToSQL()
+	if err != nil {
+		return err
+	}
+
+	_, err = repository.db.ExecContext(ctx, sql, args...)
+
+	return err
+}
+
+func (repository *webhookSql) CountDeadWebhooks(ctx context.Context) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx, "CountDeadWebhooks")
+	defer span.End()
+	defer repository.metrics.Query("CountDeadWebhooks").ObserveDuration()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	sql, args, err := goqu.From(webhookSqlTableName).
+		Select(goqu.COUNT("uuid")).
+		Where(goqu.Ex{"dead": true}).
+		ToSQL()
+	if err != nil {
+		return 0, err
+	}
+
+	count := int64(0)
+	if err := repository.db.QueryRowContext(ctx, sql, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (repository *webhookSql) PageDeadWebhooks(ctx context.Context, page uint, limit uint) ([]*Webhook, error) {
+	ctx, span := repository.tracer.Start(ctx, "PageDeadWebhooks")
+	defer span.End()
+	defer repository.metrics.Query("PageDeadWebhooks").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.Int("page", int(page)),
+		attribute.Int("limit", int(limit)),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.From(webhookSqlTableName).
+		Select(webhookColumns...).
+		Where(goqu.Ex{"dead": true}).
+		Order(goqu.C("id").Asc()).
+		Offset(page * limit).
+		Limit(limit).
+		ToSQL()
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.find(ctx, sql, args...)
+}
+
+func (repository *webhookSql) RequeueWebhook(ctx context.Context, uuid uuid.UUID, now time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx, "RequeueWebhook")
+	defer span.End()
+	defer repository.metrics.Query("RequeueWebhook").ObserveDuration()
+
+	span.SetAttributes(
+		attribute.String("uuid", uuid.String()),
+		attribute.String("repository", "sql"),
+	)
+
+	sql, args, err := goqu.Update(webhookSqlTableName).
+		Set(goqu.Record{
+			"attempts":        0,
+			"dead":            false,
+			"next_attempt_at": now.UTC(),
+		}).
+		Where(goqu.Ex{"uuid": uuid, "dead": true}).
+		ToSQL()
+	if err != nil {
+		return false, err
+	}
+
+	result, err := repository.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (repository *webhookSql) find(ctx context.Context, sql string, args ...interface{}) ([]*Webhook, error) {
+	ctx, span := repository.tracer.Start(ctx, "find")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("repository", "sql"))
+
+	rows, err := repository.db.QueryContext(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var webhooks []*Webhook
+
+	for rows.Next() {
+		webhook := &Webhook{}
+
+		err := rows.Scan(
+			&webhook.Id,
+			&webhook.Uuid,
+			&webhook.Event,
+			&webhook.Url,
+			&webhook.Payload,
+			&webhook.Attempts,
+			&webhook.LastError,
+			&webhook.Dead,
+			&webhook.NextAttemptAt,
+			&webhook.DeliveredAt,
+			&webhook.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if len(webhooks) == 0 {
+		return nil, db.RecordNotFoundError
+	}
+
+	return webhooks, nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/diez37/go-packages/closer"
+	"github.com/diez37/go-packages/container"
+	"github.com/diez37/go-packages/log"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ChainBrokenError = errors.New("audit chain is broken")
+)
+
+// newAuditCommand creating and return cobra.Command for commands of the audit log
+func newAuditCommand(container container.Container) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "commands of the audit log",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "walking of the audit chain with checking of hashes and signed checkpoints, the first break is reported",
+		// a break is the result of the command, not a wrong usage
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return container.Invoke(func(
+				logger log.Logger,
+				closer closer.Closer,
+				tracer trace.Tracer,
+				auditConfig *config.Audit,
+				auditRepository repository.AuditRepository,
+				migrator *migrate.Migrate,
+			) error {
+				if err := migrator.Up(); err != nil && err != migrate.ErrNoChange {
+					return err
+				}
+
+				auditor, err := audit.NewVerifier(auditConfig, auditRepository, logger, tracer)
+				if err != nil {
+					return err
+				}
+
+				verification, err := auditor.Verify(closer.GetContext())
+				if err != nil {
+					return err
+				}
+
+				out := cmd.OutOrStdout()
+
+				fmt.Fprintf(out, "entries: %d\n", verification.Entries)
+				fmt.Fprintf(out, "checkpoints: %d\n", verification.Checkpoints)
+				fmt.Fprintf(out, "unchained: %d\n", verification.Unchained)
+
+				if verification.Break != nil {
+					fmt.Fprintf(out, "break: id %d, uuid %s, %s\n", verification.Break.Id, verification.Break.Uuid, verification.Break.Reason)
+
+					return ChainBrokenError
+				}
+
+				fmt.Fprintln(out, "chain is valid")
+
+				return nil
+			})
+		},
+	})
+
+	return cmd
+}
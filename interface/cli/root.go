@@ -2,12 +2,24 @@ package cli
 
 import (
 	"context"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/auth"
 	"github.com/Diez37/passwords/application/blocker"
+	"github.com/Diez37/passwords/application/expiry"
+	"github.com/Diez37/passwords/application/generator"
 	"github.com/Diez37/passwords/application/hash"
+	"github.com/Diez37/passwords/application/health"
+	"github.com/Diez37/passwords/application/otp"
+	"github.com/Diez37/passwords/application/outbox"
 	"github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/application/recovery"
+	"github.com/Diez37/passwords/application/webhook"
+	"github.com/Diez37/passwords/infrastructure/certificates"
 	"github.com/Diez37/passwords/infrastructure/config"
 	container2 "github.com/Diez37/passwords/infrastructure/container"
+	"github.com/Diez37/passwords/infrastructure/metrics"
 	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/interface/grpc"
 	"github.com/Diez37/passwords/interface/http"
 	"github.com/Diez37/passwords/interface/repeater"
 	"github.com/diez37/go-packages/app"
@@ -18,8 +30,11 @@ import (
 	"github.com/diez37/go-packages/log"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
+	"strconv"
 )
 
 const (
@@ -36,31 +51,300 @@ func NewRootCommand() (*cobra.Command, error) {
 	}
 
 	cmd := &cobra.Command{
-		PreRunE: func(cmd *cobra.Command, _ []string) error {
+		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 			return container.Invoke(func(
 				generalConfig *app.Config,
 				configurator configurator.Configurator,
 				blockerConfig *config.Blocker,
 				hashConfig *config.Hash,
 				passwordConfig *config.Password,
-			) {
+				generatorConfig *config.Generator,
+				otpConfig *config.Otp,
+				recoveryConfig *config.Recovery,
+				expiryConfig *config.Expiry,
+				auditConfig *config.Audit,
+				healthConfig *config.Health,
+				webhookConfig *config.Webhook,
+				outboxConfig *config.Outbox,
+				authConfig *config.Auth,
+				tlsConfig *config.Tls,
+				grpcConfig *config.Grpc,
+			) error {
 				app.Configuration(generalConfig, configurator, app.WithAppName(AppName))
 
 				configurator.SetDefault(config.BlockerBlockIntervalFieldName, config.BlockerBlockIntervalDefault)
+				configurator.SetDefault(config.BlockerStatusLifetimeFieldName, config.BlockerStatusLifetimeDefault)
 				configurator.SetDefault(config.PasswordLifetimeFieldName, config.PasswordLifetimeDefault)
+				configurator.SetDefault(config.PasswordBatchLimitFieldName, config.PasswordBatchLimitDefault)
+				configurator.SetDefault(config.PasswordBatchParallelismFieldName, config.PasswordBatchParallelismDefault)
+				configurator.SetDefault(config.PasswordResetLifetimeFieldName, config.PasswordResetLifetimeDefault)
+				configurator.SetDefault(config.PasswordGracePeriodFieldName, config.PasswordGracePeriodDefault)
+				configurator.SetDefault(config.PasswordActiveLimitFieldName, config.PasswordActiveLimitDefault)
+				configurator.SetDefault(config.PasswordActiveLimitsFieldName, config.PasswordActiveLimitsDefault)
+				configurator.SetDefault(config.PasswordActiveOverflowFieldName, config.PasswordActiveOverflowDefault)
 				configurator.SetDefault(config.HashSaltFieldName, config.HashSaltDefault)
+				configurator.SetDefault(config.GeneratorWordsFileFieldName, config.GeneratorWordsFileDefault)
+				configurator.SetDefault(config.OtpKeyFieldName, config.OtpKeyDefault)
+				configurator.SetDefault(config.OtpIssuerFieldName, config.OtpIssuerDefault)
+				configurator.SetDefault(config.OtpSkewFieldName, config.OtpSkewDefault)
+				configurator.SetDefault(config.OtpLookAheadFieldName, config.OtpLookAheadDefault)
+				configurator.SetDefault(config.OtpPeriodFieldName, config.OtpPeriodDefault)
+				configurator.SetDefault(config.OtpDigitsFieldName, config.OtpDigitsDefault)
+				configurator.SetDefault(config.RecoveryCountFieldName, config.RecoveryCountDefault)
+				configurator.SetDefault(config.RecoveryLengthFieldName, config.RecoveryLengthDefault)
+				configurator.SetDefault(config.ExpiryIntervalFieldName, config.ExpiryIntervalDefault)
+				configurator.SetDefault(config.ExpiryThresholdsFieldName, config.ExpiryThresholdsDefault)
+				configurator.SetDefault(config.AuditKeyFileFieldName, config.AuditKeyFileDefault)
+				configurator.SetDefault(config.AuditCheckpointIntervalFieldName, config.AuditCheckpointIntervalDefault)
+				configurator.SetDefault(config.HealthRepeaterIntervalsFieldName, config.HealthRepeaterIntervalsDefault)
+				configurator.SetDefault(config.WebhookUrlsFieldName, config.WebhookUrlsDefault)
+				configurator.SetDefault(config.WebhookSecretFieldName, config.WebhookSecretDefault)
+				configurator.SetDefault(config.WebhookActionsFieldName, config.WebhookActionsDefault)
+				configurator.SetDefault(config.WebhookIntervalFieldName, config.WebhookIntervalDefault)
+				configurator.SetDefault(config.WebhookTimeoutFieldName, config.WebhookTimeoutDefault)
+				configurator.SetDefault(config.WebhookAttemptsFieldName, config.WebhookAttemptsDefault)
+				configurator.SetDefault(config.WebhookBackoffFieldName, config.WebhookBackoffDefault)
+				configurator.SetDefault(config.WebhookBackoffMaxFieldName, config.WebhookBackoffMaxDefault)
+				configurator.SetDefault(config.OutboxSinksFieldName, config.OutboxSinksDefault)
+				configurator.SetDefault(config.OutboxFileFieldName, config.OutboxFileDefault)
+				configurator.SetDefault(config.OutboxIntervalFieldName, config.OutboxIntervalDefault)
+				configurator.SetDefault(config.AuthMethodsFieldName, config.AuthMethodsDefault)
+				configurator.SetDefault(config.AuthDisabledFieldName, config.AuthDisabledDefault)
+				configurator.SetDefault(config.AuthKeysFieldName, config.AuthKeysDefault)
+				configurator.SetDefault(config.AuthHmacSecretsFieldName, config.AuthHmacSecretsDefault)
+				configurator.SetDefault(config.AuthHmacSkewFieldName, config.AuthHmacSkewDefault)
+				configurator.SetDefault(config.AuthJwtJwksFieldName, config.AuthJwtJwksDefault)
+				configurator.SetDefault(config.AuthJwtIssuerFieldName, config.AuthJwtIssuerDefault)
+				configurator.SetDefault(config.AuthJwtAudienceFieldName, config.AuthJwtAudienceDefault)
+				configurator.SetDefault(config.AuthRolesFieldName, config.AuthRolesDefault)
+				configurator.SetDefault(config.AuthMtlsIdentitiesFieldName, config.AuthMtlsIdentitiesDefault)
+				configurator.SetDefault(config.TlsCertFileFieldName, config.TlsCertFileDefault)
+				configurator.SetDefault(config.TlsKeyFileFieldName, config.TlsKeyFileDefault)
+				configurator.SetDefault(config.TlsClientCaFileFieldName, config.TlsClientCaFileDefault)
+				configurator.SetDefault(config.TlsClientRequiredFieldName, config.TlsClientRequiredDefault)
+				configurator.SetDefault(config.TlsReloadIntervalFieldName, config.TlsReloadIntervalDefault)
+				configurator.SetDefault(config.GrpcAddressFieldName, config.GrpcAddressDefault)
 
 				if blockInterval := configurator.GetDuration(config.BlockerBlockIntervalFieldName); blockerConfig.BlockInterval == config.BlockerBlockIntervalDefault {
 					blockerConfig.BlockInterval = blockInterval
 				}
 
+				if statusLifetime := configurator.GetDuration(config.BlockerStatusLifetimeFieldName); blockerConfig.StatusLifetime == config.BlockerStatusLifetimeDefault {
+					blockerConfig.StatusLifetime = statusLifetime
+				}
+
 				if lifetime := configurator.GetDuration(config.PasswordLifetimeFieldName); passwordConfig.Lifetime == config.PasswordLifetimeDefault {
 					passwordConfig.Lifetime = lifetime
 				}
 
+				if batchLimit := configurator.GetInt(config.PasswordBatchLimitFieldName); passwordConfig.BatchLimit == config.PasswordBatchLimitDefault {
+					passwordConfig.BatchLimit = batchLimit
+				}
+
+				if batchParallelism := configurator.GetInt(config.PasswordBatchParallelismFieldName); passwordConfig.BatchParallelism == config.PasswordBatchParallelismDefault {
+					passwordConfig.BatchParallelism = batchParallelism
+				}
+
+				if resetLifetime := configurator.GetDuration(config.PasswordResetLifetimeFieldName); passwordConfig.ResetLifetime == config.PasswordResetLifetimeDefault {
+					passwordConfig.ResetLifetime = resetLifetime
+				}
+
+				if gracePeriod := configurator.GetDuration(config.PasswordGracePeriodFieldName); passwordConfig.GracePeriod == config.PasswordGracePeriodDefault {
+					passwordConfig.GracePeriod = gracePeriod
+				}
+
+				if activeLimit := configurator.GetInt(config.PasswordActiveLimitFieldName); passwordConfig.ActiveLimit == config.PasswordActiveLimitDefault {
+					passwordConfig.ActiveLimit = activeLimit
+				}
+
+				// maps cannot be compared with the default, so the flag is checked for changing
+				if activeLimits := configurator.GetStringMapString(config.PasswordActiveLimitsFieldName); !cmd.PersistentFlags().Changed(config.PasswordActiveLimitsFieldName) {
+					passwordConfig.ActiveLimits = map[string]int{}
+
+					for passwordType, value := range activeLimits {
+						limit, err := strconv.Atoi(value)
+						if err != nil {
+							return err
+						}
+
+						passwordConfig.ActiveLimits[passwordType] = limit
+					}
+				}
+
+				if activeOverflow := configurator.GetString(config.PasswordActiveOverflowFieldName); passwordConfig.ActiveOverflow == config.PasswordActiveOverflowDefault {
+					passwordConfig.ActiveOverflow = activeOverflow
+				}
+
 				if salt := configurator.GetString(config.HashSaltFieldName); hashConfig.Salt == config.HashSaltDefault {
 					hashConfig.Salt = salt
 				}
+
+				if wordsFile := configurator.GetString(config.GeneratorWordsFileFieldName); generatorConfig.WordsFile == config.GeneratorWordsFileDefault {
+					generatorConfig.WordsFile = wordsFile
+				}
+
+				if key := configurator.GetString(config.OtpKeyFieldName); otpConfig.Key == config.OtpKeyDefault {
+					otpConfig.Key = key
+				}
+
+				if issuer := configurator.GetString(config.OtpIssuerFieldName); otpConfig.Issuer == config.OtpIssuerDefault {
+					otpConfig.Issuer = issuer
+				}
+
+				if skew := configurator.GetInt(config.OtpSkewFieldName); otpConfig.Skew == config.OtpSkewDefault {
+					otpConfig.Skew = skew
+				}
+
+				if lookAhead := configurator.GetInt(config.OtpLookAheadFieldName); otpConfig.LookAhead == config.OtpLookAheadDefault {
+					otpConfig.LookAhead = lookAhead
+				}
+
+				if period := configurator.GetDuration(config.OtpPeriodFieldName); otpConfig.Period == config.OtpPeriodDefault {
+					otpConfig.Period = period
+				}
+
+				if digits := configurator.GetInt(config.OtpDigitsFieldName); otpConfig.Digits == config.OtpDigitsDefault {
+					otpConfig.Digits = digits
+				}
+
+				if count := configurator.GetInt(config.RecoveryCountFieldName); recoveryConfig.Count == config.RecoveryCountDefault {
+					recoveryConfig.Count = count
+				}
+
+				if length := configurator.GetInt(config.RecoveryLengthFieldName); recoveryConfig.Length == config.RecoveryLengthDefault {
+					recoveryConfig.Length = length
+				}
+
+				if interval := configurator.GetDuration(config.ExpiryIntervalFieldName); expiryConfig.Interval == config.ExpiryIntervalDefault {
+					expiryConfig.Interval = interval
+				}
+
+				// slices cannot be compared with the default, so the flag is checked for changing
+				if thresholds := configurator.GetIntSlice(config.ExpiryThresholdsFieldName); !cmd.PersistentFlags().Changed(config.ExpiryThresholdsFieldName) {
+					expiryConfig.Thresholds = thresholds
+				}
+
+				if keyFile := configurator.GetString(config.AuditKeyFileFieldName); auditConfig.KeyFile == config.AuditKeyFileDefault {
+					auditConfig.KeyFile = keyFile
+				}
+
+				if checkpointInterval := configurator.GetDuration(config.AuditCheckpointIntervalFieldName); auditConfig.CheckpointInterval == config.AuditCheckpointIntervalDefault {
+					auditConfig.CheckpointInterval = checkpointInterval
+				}
+
+				if repeaterIntervals := configurator.GetInt(config.HealthRepeaterIntervalsFieldName); healthConfig.RepeaterIntervals == config.HealthRepeaterIntervalsDefault {
+					healthConfig.RepeaterIntervals = repeaterIntervals
+				}
+
+				if urls := configurator.GetStringSlice(config.WebhookUrlsFieldName); !cmd.PersistentFlags().Changed(config.WebhookUrlsFieldName) {
+					webhookConfig.Urls = urls
+				}
+
+				if secret := configurator.GetString(config.WebhookSecretFieldName); webhookConfig.Secret == config.WebhookSecretDefault {
+					webhookConfig.Secret = secret
+				}
+
+				if actions := configurator.GetStringSlice(config.WebhookActionsFieldName); !cmd.PersistentFlags().Changed(config.WebhookActionsFieldName) {
+					webhookConfig.Actions = actions
+				}
+
+				if interval := configurator.GetDuration(config.WebhookIntervalFieldName); webhookConfig.Interval == config.WebhookIntervalDefault {
+					webhookConfig.Interval = interval
+				}
+
+				if timeout := configurator.GetDuration(config.WebhookTimeoutFieldName); webhookConfig.Timeout == config.WebhookTimeoutDefault {
+					webhookConfig.Timeout = timeout
+				}
+
+				if attempts := configurator.GetInt(config.WebhookAttemptsFieldName); webhookConfig.Attempts == config.WebhookAttemptsDefault {
+					webhookConfig.Attempts = attempts
+				}
+
+				if backoff := configurator.GetDuration(config.WebhookBackoffFieldName); webhookConfig.Backoff == config.WebhookBackoffDefault {
+					webhookConfig.Backoff = backoff
+				}
+
+				if backoffMax := configurator.GetDuration(config.WebhookBackoffMaxFieldName); webhookConfig.BackoffMax == config.WebhookBackoffMaxDefault {
+					webhookConfig.BackoffMax = backoffMax
+				}
+
+				if sinks := configurator.GetStringSlice(config.OutboxSinksFieldName); !cmd.PersistentFlags().Changed(config.OutboxSinksFieldName) {
+					outboxConfig.Sinks = sinks
+				}
+
+				if file := configurator.GetString(config.OutboxFileFieldName); outboxConfig.File == config.OutboxFileDefault {
+					outboxConfig.File = file
+				}
+
+				if interval := configurator.GetDuration(config.OutboxIntervalFieldName); outboxConfig.Interval == config.OutboxIntervalDefault {
+					outboxConfig.Interval = interval
+				}
+
+				if methods := configurator.GetStringSlice(config.AuthMethodsFieldName); !cmd.PersistentFlags().Changed(config.AuthMethodsFieldName) {
+					authConfig.Methods = methods
+				}
+
+				if disabled := configurator.GetBool(config.AuthDisabledFieldName); authConfig.Disabled == config.AuthDisabledDefault {
+					authConfig.Disabled = disabled
+				}
+
+				if keys := configurator.GetStringMapString(config.AuthKeysFieldName); !cmd.PersistentFlags().Changed(config.AuthKeysFieldName) {
+					authConfig.Keys = keys
+				}
+
+				if secrets := configurator.GetStringMapString(config.AuthHmacSecretsFieldName); !cmd.PersistentFlags().Changed(config.AuthHmacSecretsFieldName) {
+					authConfig.HmacSecrets = secrets
+				}
+
+				if skew := configurator.GetDuration(config.AuthHmacSkewFieldName); authConfig.HmacSkew == config.AuthHmacSkewDefault {
+					authConfig.HmacSkew = skew
+				}
+
+				if jwks := configurator.GetString(config.AuthJwtJwksFieldName); authConfig.JwtJwks == config.AuthJwtJwksDefault {
+					authConfig.JwtJwks = jwks
+				}
+
+				if issuer := configurator.GetString(config.AuthJwtIssuerFieldName); authConfig.JwtIssuer == config.AuthJwtIssuerDefault {
+					authConfig.JwtIssuer = issuer
+				}
+
+				if audience := configurator.GetString(config.AuthJwtAudienceFieldName); authConfig.JwtAudience == config.AuthJwtAudienceDefault {
+					authConfig.JwtAudience = audience
+				}
+
+				if roles := configurator.GetStringMapString(config.AuthRolesFieldName); !cmd.PersistentFlags().Changed(config.AuthRolesFieldName) {
+					authConfig.Roles = roles
+				}
+
+				if identities := configurator.GetStringMapString(config.AuthMtlsIdentitiesFieldName); !cmd.PersistentFlags().Changed(config.AuthMtlsIdentitiesFieldName) {
+					authConfig.MtlsIdentities = identities
+				}
+
+				if certFile := configurator.GetString(config.TlsCertFileFieldName); tlsConfig.CertFile == config.TlsCertFileDefault {
+					tlsConfig.CertFile = certFile
+				}
+
+				if keyFile := configurator.GetString(config.TlsKeyFileFieldName); tlsConfig.KeyFile == config.TlsKeyFileDefault {
+					tlsConfig.KeyFile = keyFile
+				}
+
+				if clientCaFile := configurator.GetString(config.TlsClientCaFileFieldName); tlsConfig.ClientCaFile == config.TlsClientCaFileDefault {
+					tlsConfig.ClientCaFile = clientCaFile
+				}
+
+				if clientRequired := configurator.GetBool(config.TlsClientRequiredFieldName); tlsConfig.ClientRequired == config.TlsClientRequiredDefault {
+					tlsConfig.ClientRequired = clientRequired
+				}
+
+				if reloadInterval := configurator.GetDuration(config.TlsReloadIntervalFieldName); tlsConfig.ReloadInterval == config.TlsReloadIntervalDefault {
+					tlsConfig.ReloadInterval = reloadInterval
+				}
+
+				if address := configurator.GetString(config.GrpcAddressFieldName); grpcConfig.Address == config.GrpcAddressDefault {
+					grpcConfig.Address = address
+				}
+
+				return nil
 			})
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -73,25 +357,107 @@ func NewRootCommand() (*cobra.Command, error) {
 				hashConfig *config.Hash,
 				passwordConfig *config.Password,
 				blockerConfig *config.Blocker,
+				generatorConfig *config.Generator,
+				otpConfig *config.Otp,
+				otpRepository repository.OtpRepository,
+				recoveryConfig *config.Recovery,
+				recoveryRepository repository.RecoveryRepository,
+				auditRepository repository.AuditRepository,
+				auditConfig *config.Audit,
+				expiryConfig *config.Expiry,
+				metrics *metrics.Metrics,
+				healthConfig *config.Health,
+				webhookConfig *config.Webhook,
+				webhookRepository repository.WebhookRepository,
+				outboxConfig *config.Outbox,
+				outboxRepository repository.OutboxRepository,
+				authConfig *config.Auth,
+				tlsConfig *config.Tls,
 				migrator *migrate.Migrate,
 			) error {
 				logger.Infof("app: %s started", generalConfig.Name)
 				logger.Infof("app: pid - %d", generalConfig.PID)
 
+				health := health.NewHealth(healthConfig, repository, migrator, tracer)
+
 				if err := migrator.Up(); err != nil && err != migrate.ErrNoChange {
 					return err
 				}
 
-				hasher := hash.NewCrypto(hashConfig, tracer)
-				blocker := blocker.NewBlocker(repository, tracer)
-				password := password.NewPassword(passwordConfig, hasher, repository, tracer, blocker)
+				health.Migrated()
+
+				auditor, err := audit.NewAuditor(auditConfig, auditRepository, logger, tracer)
+				if err != nil {
+					return err
+				}
+
+				dispatcher, err := webhook.NewDispatcher(webhookConfig, webhookRepository, logger, tracer)
+				if err != nil {
+					return err
+				}
+
+				outbox, err := outbox.NewOutbox(outboxConfig, outboxRepository, logger, tracer, map[string]outbox.Sink{
+					config.LogOutboxSink:     outbox.NewLogSink(logger, tracer),
+					config.FileOutboxSink:    outbox.NewFileSink(outboxConfig.File, tracer),
+					config.WebhookOutboxSink: dispatcher,
+				})
+				if err != nil {
+					return err
+				}
+
+				hasher := hash.NewCrypto(hashConfig, tracer, metrics)
+				blocker := blocker.NewBlocker(blockerConfig, repository, tracer, auditor, outbox, metrics)
+				password := password.NewPassword(passwordConfig, hasher, repository, tracer, blocker, auditor, outbox, metrics)
+
+				generator, err := generator.NewGenerator(generatorConfig, tracer)
+				if err != nil {
+					return err
+				}
+
+				// otp routes are not served without the key
+				var otpService otp.Service
+				if otpConfig.Enabled() {
+					if otpService, err = otp.NewOtp(otpConfig, otpRepository, tracer); err != nil {
+						return err
+					}
+				} else {
+					logger.Infof("otp: disabled, %s is not set", config.OtpKeyFieldName)
+				}
+
+				authenticator, err := auth.NewAuthenticator(authConfig, tracer)
+				if err != nil {
+					return err
+				}
+
+				recovery := recovery.NewRecovery(recoveryConfig, hashConfig, generator, recoveryRepository, tracer, auditor, outbox, metrics)
+				expiry := expiry.NewExpiry(expiryConfig, expiry.NewLogNotifier(logger, tracer), repository, tracer)
+
+				// the trace context of callers is extracted from the grpc metadata by the propagator
+				otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+				var tlsCertificates *certificates.Certificates
+				if tlsConfig.CertFile != "" {
+					tlsCertificates, err = certificates.NewCertificates(tlsConfig)
+					if err != nil {
+						return err
+					}
+				}
 
 				ctx, cancelFunc := context.WithCancel(closer.GetContext())
 				defer cancelFunc()
 
 				wg := &errgroup.Group{}
 				wg.Go(func() error {
-					if err := http.Serve(ctx, container, logger, password, blocker); err != nil {
+					if err := http.Serve(ctx, container, logger, password, blocker, generator, otpService, recovery, auditor, dispatcher, authenticator, health, tlsCertificates); err != nil {
+						cancelFunc()
+						return err
+					}
+
+					return nil
+				})
+
+				wg.Go(func() error {
+					if err := grpc.Serve(ctx, container, logger, password, blocker, authenticator, tlsCertificates); err != nil {
 						cancelFunc()
 						return err
 					}
@@ -100,7 +466,21 @@ func NewRootCommand() (*cobra.Command, error) {
 				})
 
 				wg.Go(func() error {
-					repeater.Serve(ctx, blockerConfig, logger, blocker)
+					auditor.Write(ctx)
+
+					return nil
+				})
+
+				if tlsCertificates != nil {
+					wg.Go(func() error {
+						tlsCertificates.Watch(ctx, logger)
+
+						return nil
+					})
+				}
+
+				wg.Go(func() error {
+					repeater.Serve(ctx, blockerConfig, expiryConfig, auditConfig, webhookConfig, outboxConfig, logger, metrics, blocker, expiry, auditor, dispatcher, outbox, health)
 
 					return nil
 				})
@@ -119,11 +499,77 @@ func NewRootCommand() (*cobra.Command, error) {
 		return nil, err
 	}
 
-	container.Invoke(func(blockerConfig *config.Blocker, hashConfig *config.Hash, passwordConfig *config.Password) {
+	container.Invoke(func(
+		blockerConfig *config.Blocker,
+		hashConfig *config.Hash,
+		passwordConfig *config.Password,
+		generatorConfig *config.Generator,
+		otpConfig *config.Otp,
+		recoveryConfig *config.Recovery,
+		expiryConfig *config.Expiry,
+		auditConfig *config.Audit,
+		healthConfig *config.Health,
+		webhookConfig *config.Webhook,
+		outboxConfig *config.Outbox,
+		authConfig *config.Auth,
+		tlsConfig *config.Tls,
+		grpcConfig *config.Grpc,
+	) {
 		cmd.PersistentFlags().DurationVar(&blockerConfig.BlockInterval, config.BlockerBlockIntervalFieldName, config.BlockerBlockIntervalDefault, "")
+		cmd.PersistentFlags().DurationVar(&blockerConfig.StatusLifetime, config.BlockerStatusLifetimeFieldName, config.BlockerStatusLifetimeDefault, "")
 		cmd.PersistentFlags().DurationVar(&passwordConfig.Lifetime, config.PasswordLifetimeFieldName, config.PasswordLifetimeDefault, "")
+		cmd.PersistentFlags().IntVar(&passwordConfig.BatchLimit, config.PasswordBatchLimitFieldName, config.PasswordBatchLimitDefault, "maximum count of passwords in one batch request")
+		cmd.PersistentFlags().IntVar(&passwordConfig.BatchParallelism, config.PasswordBatchParallelismFieldName, config.PasswordBatchParallelismDefault, "maximum count of passwords processing at the same time in one batch")
+		cmd.PersistentFlags().DurationVar(&passwordConfig.ResetLifetime, config.PasswordResetLifetimeFieldName, config.PasswordResetLifetimeDefault, "time during which the reset token can be redeemed")
+		cmd.PersistentFlags().DurationVar(&passwordConfig.GracePeriod, config.PasswordGracePeriodFieldName, config.PasswordGracePeriodDefault, "time after the end of validity during which the password is accepted as expired")
+		cmd.PersistentFlags().IntVar(&passwordConfig.ActiveLimit, config.PasswordActiveLimitFieldName, config.PasswordActiveLimitDefault, "maximum count of active passwords of one login, 0 is unlimited")
+		cmd.PersistentFlags().StringToIntVar(&passwordConfig.ActiveLimits, config.PasswordActiveLimitsFieldName, config.PasswordActiveLimitsDefault, "maximum count of active passwords of one login by types, e.g. app=5,token=3")
+		cmd.PersistentFlags().StringVar(&passwordConfig.ActiveOverflow, config.PasswordActiveOverflowFieldName, config.PasswordActiveOverflowDefault, "strategy on exceeding of the limit of active passwords: reject or evict")
 		cmd.PersistentFlags().StringVar(&hashConfig.Salt, config.HashSaltFieldName, config.HashSaltDefault, "")
+		cmd.PersistentFlags().StringVar(&otpConfig.Key, config.OtpKeyFieldName, config.OtpKeyDefault, "secret for encryption of otp secrets, empty disables otp")
+		cmd.PersistentFlags().StringVar(&otpConfig.Issuer, config.OtpIssuerFieldName, config.OtpIssuerDefault, "issuer in otpauth:// uri")
+		cmd.PersistentFlags().IntVar(&otpConfig.Skew, config.OtpSkewFieldName, config.OtpSkewDefault, "count of totp time steps accepted before and after the current one")
+		cmd.PersistentFlags().IntVar(&otpConfig.LookAhead, config.OtpLookAheadFieldName, config.OtpLookAheadDefault, "count of hotp counter values accepted after the expected one")
+		cmd.PersistentFlags().DurationVar(&otpConfig.Period, config.OtpPeriodFieldName, config.OtpPeriodDefault, "totp time step, whole seconds")
+		cmd.PersistentFlags().IntVar(&otpConfig.Digits, config.OtpDigitsFieldName, config.OtpDigitsDefault, "count of digits in otp codes, from 6 to 8")
+		cmd.PersistentFlags().IntVar(&recoveryConfig.Count, config.RecoveryCountFieldName, config.RecoveryCountDefault, "count of recovery codes in one batch")
+		cmd.PersistentFlags().IntVar(&recoveryConfig.Length, config.RecoveryLengthFieldName, config.RecoveryLengthDefault, "length of one recovery code")
+		cmd.PersistentFlags().DurationVar(&expiryConfig.Interval, config.ExpiryIntervalFieldName, config.ExpiryIntervalDefault, "interval between searches of expiring passwords")
+		cmd.PersistentFlags().IntSliceVar(&expiryConfig.Thresholds, config.ExpiryThresholdsFieldName, config.ExpiryThresholdsDefault, "days before the end of validity when notifications are sent")
+		cmd.PersistentFlags().StringVar(&auditConfig.KeyFile, config.AuditKeyFileFieldName, config.AuditKeyFileDefault, "file with the key for signing of audit checkpoints, it is generated by the server if missing, audit verify requires the existing one")
+		cmd.PersistentFlags().DurationVar(&auditConfig.CheckpointInterval, config.AuditCheckpointIntervalFieldName, config.AuditCheckpointIntervalDefault, "interval between signed checkpoints of the audit chain")
+		cmd.PersistentFlags().IntVar(&healthConfig.RepeaterIntervals, config.HealthRepeaterIntervalsFieldName, config.HealthRepeaterIntervalsDefault, "count of intervals of a repeater task without a completed cycle after which the service is not ready")
+		cmd.PersistentFlags().StringSliceVar(&webhookConfig.Urls, config.WebhookUrlsFieldName, config.WebhookUrlsDefault, "urls receiving events, empty disables webhooks")
+		cmd.PersistentFlags().StringVar(&webhookConfig.Secret, config.WebhookSecretFieldName, config.WebhookSecretDefault, "key of HMAC-SHA256 signature of webhook payloads, required when webhook urls are set")
+		cmd.PersistentFlags().StringSliceVar(&webhookConfig.Actions, config.WebhookActionsFieldName, config.WebhookActionsDefault, "audit actions sent to webhooks")
+		cmd.PersistentFlags().DurationVar(&webhookConfig.Interval, config.WebhookIntervalFieldName, config.WebhookIntervalDefault, "interval between deliveries of the webhook outbox")
+		cmd.PersistentFlags().DurationVar(&webhookConfig.Timeout, config.WebhookTimeoutFieldName, config.WebhookTimeoutDefault, "timeout of one webhook request")
+		cmd.PersistentFlags().IntVar(&webhookConfig.Attempts, config.WebhookAttemptsFieldName, config.WebhookAttemptsDefault, "count of failed attempts after which the delivery is dead")
+		cmd.PersistentFlags().DurationVar(&webhookConfig.Backoff, config.WebhookBackoffFieldName, config.WebhookBackoffDefault, "delay after the first failed delivery, doubled after every next one")
+		cmd.PersistentFlags().DurationVar(&webhookConfig.BackoffMax, config.WebhookBackoffMaxFieldName, config.WebhookBackoffMaxDefault, "maximum delay between delivery attempts")
+		cmd.PersistentFlags().StringSliceVar(&outboxConfig.Sinks, config.OutboxSinksFieldName, config.OutboxSinksDefault, "sinks receiving events of the outbox: log, file, webhook")
+		cmd.PersistentFlags().StringVar(&outboxConfig.File, config.OutboxFileFieldName, config.OutboxFileDefault, "file of the file sink of the outbox, events are appended as json lines")
+		cmd.PersistentFlags().DurationVar(&outboxConfig.Interval, config.OutboxIntervalFieldName, config.OutboxIntervalDefault, "interval between relays of the outbox")
+		cmd.PersistentFlags().StringSliceVar(&authConfig.Methods, config.AuthMethodsFieldName, config.AuthMethodsDefault, "accepted methods of caller authentication: apikey, hmac, jwt, mtls, required unless auth.disabled")
+		cmd.PersistentFlags().BoolVar(&authConfig.Disabled, config.AuthDisabledFieldName, config.AuthDisabledDefault, "disable authentication, every caller is the administrator")
+		cmd.PersistentFlags().StringToStringVar(&authConfig.Keys, config.AuthKeysFieldName, config.AuthKeysDefault, "hex encoded SHA-256 hashes of api keys by callers, e.g. frontend=<sha256 of the key>")
+		cmd.PersistentFlags().StringToStringVar(&authConfig.HmacSecrets, config.AuthHmacSecretsFieldName, config.AuthHmacSecretsDefault, "secrets of signed requests by callers, e.g. billing=<secret>")
+		cmd.PersistentFlags().DurationVar(&authConfig.HmacSkew, config.AuthHmacSkewFieldName, config.AuthHmacSkewDefault, "maximum difference between the timestamp of the signed request and the current time")
+		cmd.PersistentFlags().StringVar(&authConfig.JwtJwks, config.AuthJwtJwksFieldName, config.AuthJwtJwksDefault, "JWKS file verifying bearer tokens")
+		cmd.PersistentFlags().StringVar(&authConfig.JwtIssuer, config.AuthJwtIssuerFieldName, config.AuthJwtIssuerDefault, "required issuer of bearer tokens, empty skips the check")
+		cmd.PersistentFlags().StringVar(&authConfig.JwtAudience, config.AuthJwtAudienceFieldName, config.AuthJwtAudienceDefault, "required audience of bearer tokens, empty skips the check")
+		cmd.PersistentFlags().StringToStringVar(&authConfig.Roles, config.AuthRolesFieldName, config.AuthRolesDefault, "roles by callers namespaced by methods, check or admin, e.g. apikey:frontend=check,jwt:console=admin")
+		cmd.PersistentFlags().StringToStringVar(&authConfig.MtlsIdentities, config.AuthMtlsIdentitiesFieldName, config.AuthMtlsIdentitiesDefault, "callers by names of client certificates, e.g. cn:frontend=frontend,dns:billing.internal=billing")
+		cmd.PersistentFlags().StringVar(&tlsConfig.CertFile, config.TlsCertFileFieldName, config.TlsCertFileDefault, "PEM certificate chain of the server, empty serves plain http")
+		cmd.PersistentFlags().StringVar(&tlsConfig.KeyFile, config.TlsKeyFileFieldName, config.TlsKeyFileDefault, "PEM private key of the server certificate")
+		cmd.PersistentFlags().StringVar(&tlsConfig.ClientCaFile, config.TlsClientCaFileFieldName, config.TlsClientCaFileDefault, "PEM bundle of authorities of client certificates, empty disables client certificates")
+		cmd.PersistentFlags().BoolVar(&tlsConfig.ClientRequired, config.TlsClientRequiredFieldName, config.TlsClientRequiredDefault, "reject connections without the verified client certificate")
+		cmd.PersistentFlags().DurationVar(&tlsConfig.ReloadInterval, config.TlsReloadIntervalFieldName, config.TlsReloadIntervalDefault, "interval between checks of modification of tls files, 0 disables reloading")
+		cmd.PersistentFlags().StringVar(&grpcConfig.Address, config.GrpcAddressFieldName, config.GrpcAddressDefault, "listening address of the grpc server, empty disables the grpc server")
+		cmd.PersistentFlags().StringVar(&generatorConfig.WordsFile, config.GeneratorWordsFileFieldName, config.GeneratorWordsFileDefault, "file with words for passphrases, one word per line")
 	})
 
+	cmd.AddCommand(newAuditCommand(container))
+
 	return cmd, nil
 }
@@ -0,0 +1,153 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/auth"
+	"github.com/Diez37/passwords/domain"
+	"github.com/diez37/go-packages/log"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"net/http"
+)
+
+const RequestIdMetadataName = "x-request-id"
+
+// roles required roles of methods of the Passwords service, they are the roles of the mirrored http routes
+var roles = map[string]domain.Role{
+	Passwords_Add_FullMethodName:           domain.AdminRole,
+	Passwords_Check_FullMethodName:         domain.CheckRole,
+	Passwords_Change_FullMethodName:        domain.AdminRole,
+	Passwords_Delete_FullMethodName:        domain.AdminRole,
+	Passwords_DeleteByLogin_FullMethodName: domain.AdminRole,
+	Passwords_List_FullMethodName:          domain.AdminRole,
+	Passwords_AddBatch_FullMethodName:      domain.AdminRole,
+	Passwords_CheckBatch_FullMethodName:    domain.CheckRole,
+}
+
+// metadataCarrier propagation.TextMapCarrier over the incoming metadata
+type metadataCarrier metadata.MD
+
+func (carrier metadataCarrier) Get(key string) string {
+	if values := metadata.MD(carrier).Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+func (carrier metadataCarrier) Set(key string, value string) {
+	metadata.MD(carrier).Set(key, value)
+}
+
+func (carrier metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(carrier))
+	for key := range carrier {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// propagate extraction of the trace context of the caller from the incoming metadata with
+// the global text map propagator, spans of the call become children of the span of the caller
+func propagate(ctx context.Context, request interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	return handler(otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md)), request)
+}
+
+// auditCall passing of id and client address of the call to audit entries of services,
+// the id is taken from the RequestIdMetadataName metadata or generated
+func auditCall(ctx context.Context, request interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	id := metadataCarrier(md).Get(RequestIdMetadataName)
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	address := ""
+	if peer, ok := peer.FromContext(ctx); ok {
+		address = peer.Addr.String()
+	}
+
+	return handler(audit.WithRequest(ctx, id, address), request)
+}
+
+// authorize authentication of the caller of the call and rejection of callers without the role of the method,
+// the caller is passed to the server by auth.WithCaller
+func authorize(authenticator auth.Authenticator, logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		role, ok := roles[info.FullMethod]
+		if !ok {
+			return nil, status.Error(codes.Unimplemented, http.StatusText(http.StatusNotImplemented))
+		}
+
+		httpRequest, err := toHttpRequest(ctx, request, info)
+		if err != nil {
+			logger.Error(err)
+			return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+		}
+
+		caller, err := authenticator.Authenticate(ctx, httpRequest)
+		if err != nil && err != auth.UnauthenticatedError {
+			logger.Error(err)
+			return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+		}
+
+		if err == auth.UnauthenticatedError {
+			return nil, status.Error(codes.Unauthenticated, http.StatusText(http.StatusUnauthorized))
+		}
+
+		if !caller.Has(role) {
+			return nil, status.Error(codes.PermissionDenied, http.StatusText(http.StatusForbidden))
+		}
+
+		return handler(auth.WithCaller(ctx, caller), request)
+	}
+}
+
+// toHttpRequest request of the call for the authenticator: metadata is passed as headers, the verified client
+// certificate as the tls state, signed calls are signed as POST of the full method name with the deterministic
+// protobuf encoding of the request as the body
+func toHttpRequest(ctx context.Context, request interface{}, info *grpc.UnaryServerInfo) (*http.Request, error) {
+	var body []byte
+
+	if message, ok := request.(proto.Message); ok {
+		content, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		if err != nil {
+			return nil, err
+		}
+
+		body = content
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, info.FullMethod, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		for _, value := range values {
+			httpRequest.Header.Add(key, value)
+		}
+	}
+
+	if peer, ok := peer.FromContext(ctx); ok {
+		if info, ok := peer.AuthInfo.(credentials.TLSInfo); ok {
+			httpRequest.TLS = &info.State
+		}
+	}
+
+	return httpRequest, nil
+}
@@ -0,0 +1,87 @@
+package grpc
+
+import (
+	"github.com/Diez37/passwords/domain"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+// password validated fields of the Password message, rules are the rules of the http interface
+type password struct {
+	Login    uuid.UUID `validate:"required"`
+	Password string    `validate:"required"`
+	Type     string    `validate:"omitempty,oneof=primary app temporary token"`
+	Scopes   []string  `validate:"dive,required,max=64,excludesall=0x2C"`
+	Label    string    `validate:"max=255"`
+	MaxUses  int64     `validate:"min=0"`
+}
+
+func (server *Server) toDomain(message *Password) (*domain.Password, error) {
+	login, err := uuid.Parse(message.GetLogin())
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.validator.Struct(&password{
+		Login:    login,
+		Password: message.GetPassword(),
+		Type:     message.GetType(),
+		Scopes:   message.GetScopes(),
+		Label:    message.GetLabel(),
+		MaxUses:  message.GetMaxUses(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Password{
+		Login:      login,
+		Password:   message.GetPassword(),
+		OneTime:    message.GetOneTime(),
+		ValidUntil: toTime(message.GetValidUntil()),
+		ValidFrom:  toTime(message.GetValidFrom()),
+		MustChange: message.GetMustChange(),
+		Type:       domain.PasswordType(message.GetType()),
+		Scopes:     message.GetScopes(),
+		Label:      message.GetLabel(),
+		MaxUses:    message.GetMaxUses(),
+	}, nil
+}
+
+func newPasswordForPage(password *domain.Password) *PasswordForPage {
+	return &PasswordForPage{
+		Uuid:       password.Uuid.String(),
+		Login:      password.Login.String(),
+		OneTime:    password.OneTime,
+		ValidUntil: toTimestamp(password.ValidUntil),
+		ValidFrom:  toTimestamp(password.ValidFrom),
+		MustChange: password.MustChange,
+		Disabled:   password.Disabled,
+		Type:       string(password.Type),
+		Scopes:     password.Scopes,
+		Label:      password.Label,
+		MaxUses:    password.MaxUses,
+		UseCount:   password.UseCount,
+		LastUsedAt: toTimestamp(password.LastUsedAt),
+	}
+}
+
+// toTime nil for the missing timestamp, fields without a value are optional like in the http interface
+func toTime(timestamp *timestamppb.Timestamp) *time.Time {
+	if timestamp == nil {
+		return nil
+	}
+
+	value := timestamp.AsTime()
+
+	return &value
+}
+
+func toTimestamp(value *time.Time) *timestamppb.Timestamp {
+	if value == nil {
+		return nil
+	}
+
+	return timestamppb.New(*value)
+}
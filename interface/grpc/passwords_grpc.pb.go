@@ -0,0 +1,390 @@
+// Contract of the gRPC interface mirroring the /api/v1/password routes of the http interface.
+//
+// Stubs are generated into this package with protoc-gen-go and protoc-gen-go-grpc:
+//
+//   protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative passwords.proto
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: passwords.proto
+
+package grpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Passwords_Add_FullMethodName           = "/passwords.v1.Passwords/Add"
+	Passwords_Check_FullMethodName         = "/passwords.v1.Passwords/Check"
+	Passwords_Change_FullMethodName        = "/passwords.v1.Passwords/Change"
+	Passwords_Delete_FullMethodName        = "/passwords.v1.Passwords/Delete"
+	Passwords_DeleteByLogin_FullMethodName = "/passwords.v1.Passwords/DeleteByLogin"
+	Passwords_List_FullMethodName          = "/passwords.v1.Passwords/List"
+	Passwords_AddBatch_FullMethodName      = "/passwords.v1.Passwords/AddBatch"
+	Passwords_CheckBatch_FullMethodName    = "/passwords.v1.Passwords/CheckBatch"
+)
+
+// PasswordsClient is the client API for Passwords service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PasswordsClient interface {
+	// Add mirrors PUT /api/v1/password
+	Add(ctx context.Context, in *Password, opts ...grpc.CallOption) (*Result, error)
+	// Check mirrors OPTIONS /api/v1/password
+	Check(ctx context.Context, in *Password, opts ...grpc.CallOption) (*Result, error)
+	// Change mirrors PATCH /api/v1/password/{uuid}
+	Change(ctx context.Context, in *PasswordPatch, opts ...grpc.CallOption) (*PasswordForPage, error)
+	// Delete mirrors DELETE /api/v1/password/{uuid}
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*Deletion, error)
+	// DeleteByLogin mirrors DELETE /api/v1/passwords/{login}
+	DeleteByLogin(ctx context.Context, in *DeleteByLoginRequest, opts ...grpc.CallOption) (*Disabled, error)
+	// List mirrors GET /api/v1/passwords/{login}
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*Page, error)
+	// AddBatch mirrors PUT /api/v1/password/batch
+	AddBatch(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Results, error)
+	// CheckBatch mirrors OPTIONS /api/v1/password/batch
+	CheckBatch(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Results, error)
+}
+
+type passwordsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPasswordsClient(cc grpc.ClientConnInterface) PasswordsClient {
+	return &passwordsClient{cc}
+}
+
+func (c *passwordsClient) Add(ctx context.Context, in *Password, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, Passwords_Add_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordsClient) Check(ctx context.Context, in *Password, opts ...grpc.CallOption) (*Result, error) {
+	out := new(Result)
+	err := c.cc.Invoke(ctx, Passwords_Check_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordsClient) Change(ctx context.Context, in *PasswordPatch, opts ...grpc.CallOption) (*PasswordForPage, error) {
+	out := new(PasswordForPage)
+	err := c.cc.Invoke(ctx, Passwords_Change_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordsClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*Deletion, error) {
+	out := new(Deletion)
+	err := c.cc.Invoke(ctx, Passwords_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordsClient) DeleteByLogin(ctx context.Context, in *DeleteByLoginRequest, opts ...grpc.CallOption) (*Disabled, error) {
+	out := new(Disabled)
+	err := c.cc.Invoke(ctx, Passwords_DeleteByLogin_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordsClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*Page, error) {
+	out := new(Page)
+	err := c.cc.Invoke(ctx, Passwords_List_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordsClient) AddBatch(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Results, error) {
+	out := new(Results)
+	err := c.cc.Invoke(ctx, Passwords_AddBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *passwordsClient) CheckBatch(ctx context.Context, in *Batch, opts ...grpc.CallOption) (*Results, error) {
+	out := new(Results)
+	err := c.cc.Invoke(ctx, Passwords_CheckBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PasswordsServer is the server API for Passwords service.
+// All implementations must embed UnimplementedPasswordsServer
+// for forward compatibility
+type PasswordsServer interface {
+	// Add mirrors PUT /api/v1/password
+	Add(context.Context, *Password) (*Result, error)
+	// Check mirrors OPTIONS /api/v1/password
+	Check(context.Context, *Password) (*Result, error)
+	// Change mirrors PATCH /api/v1/password/{uuid}
+	Change(context.Context, *PasswordPatch) (*PasswordForPage, error)
+	// Delete mirrors DELETE /api/v1/password/{uuid}
+	Delete(context.Context, *DeleteRequest) (*Deletion, error)
+	// DeleteByLogin mirrors DELETE /api/v1/passwords/{login}
+	DeleteByLogin(context.Context, *DeleteByLoginRequest) (*Disabled, error)
+	// List mirrors GET /api/v1/passwords/{login}
+	List(context.Context, *ListRequest) (*Page, error)
+	// AddBatch mirrors PUT /api/v1/password/batch
+	AddBatch(context.Context, *Batch) (*Results, error)
+	// CheckBatch mirrors OPTIONS /api/v1/password/batch
+	CheckBatch(context.Context, *Batch) (*Results, error)
+	mustEmbedUnimplementedPasswordsServer()
+}
+
+// UnimplementedPasswordsServer must be embedded to have forward compatible implementations.
+type UnimplementedPasswordsServer struct {
+}
+
+func (UnimplementedPasswordsServer) Add(context.Context, *Password) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
+}
+func (UnimplementedPasswordsServer) Check(context.Context, *Password) (*Result, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedPasswordsServer) Change(context.Context, *PasswordPatch) (*PasswordForPage, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Change not implemented")
+}
+func (UnimplementedPasswordsServer) Delete(context.Context, *DeleteRequest) (*Deletion, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedPasswordsServer) DeleteByLogin(context.Context, *DeleteByLoginRequest) (*Disabled, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteByLogin not implemented")
+}
+func (UnimplementedPasswordsServer) List(context.Context, *ListRequest) (*Page, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedPasswordsServer) AddBatch(context.Context, *Batch) (*Results, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBatch not implemented")
+}
+func (UnimplementedPasswordsServer) CheckBatch(context.Context, *Batch) (*Results, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckBatch not implemented")
+}
+func (UnimplementedPasswordsServer) mustEmbedUnimplementedPasswordsServer() {}
+
+// UnsafePasswordsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PasswordsServer will
+// result in compilation errors.
+type UnsafePasswordsServer interface {
+	mustEmbedUnimplementedPasswordsServer()
+}
+
+func RegisterPasswordsServer(s grpc.ServiceRegistrar, srv PasswordsServer) {
+	s.RegisterService(&Passwords_ServiceDesc, srv)
+}
+
+func _Passwords_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Password)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordsServer).Add(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwords_Add_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordsServer).Add(ctx, req.(*Password))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Passwords_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Password)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordsServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwords_Check_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordsServer).Check(ctx, req.(*Password))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Passwords_Change_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordPatch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordsServer).Change(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwords_Change_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordsServer).Change(ctx, req.(*PasswordPatch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Passwords_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordsServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwords_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordsServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Passwords_DeleteByLogin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteByLoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordsServer).DeleteByLogin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwords_DeleteByLogin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordsServer).DeleteByLogin(ctx, req.(*DeleteByLoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Passwords_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwords_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordsServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Passwords_AddBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Batch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordsServer).AddBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwords_AddBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordsServer).AddBatch(ctx, req.(*Batch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Passwords_CheckBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Batch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PasswordsServer).CheckBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Passwords_CheckBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PasswordsServer).CheckBatch(ctx, req.(*Batch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Passwords_ServiceDesc is the grpc.ServiceDesc for Passwords service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Passwords_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "passwords.v1.Passwords",
+	HandlerType: (*PasswordsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Add",
+			Handler:    _Passwords_Add_Handler,
+		},
+		{
+			MethodName: "Check",
+			Handler:    _Passwords_Check_Handler,
+		},
+		{
+			MethodName: "Change",
+			Handler:    _Passwords_Change_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _Passwords_Delete_Handler,
+		},
+		{
+			MethodName: "DeleteByLogin",
+			Handler:    _Passwords_DeleteByLogin_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Passwords_List_Handler,
+		},
+		{
+			MethodName: "AddBatch",
+			Handler:    _Passwords_AddBatch_Handler,
+		},
+		{
+			MethodName: "CheckBatch",
+			Handler:    _Passwords_CheckBatch_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "passwords.proto",
+}
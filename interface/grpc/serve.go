@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"context"
+	"github.com/Diez37/passwords/application/auth"
+	"github.com/Diez37/passwords/application/blocker"
+	service "github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/infrastructure/certificates"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/diez37/go-packages/container"
+	"github.com/diez37/go-packages/log"
+	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"net"
+)
+
+// Serve configuration and running grpc server, tls is terminated with certificates unless they are nil
+func Serve(
+	ctx context.Context,
+	container container.Container,
+	logger log.Logger,
+	service service.Service,
+	blocker blocker.Blocker,
+	authenticator auth.Authenticator,
+	certificates *certificates.Certificates,
+) error {
+	ctx, cancelFunc := context.WithCancel(ctx)
+	defer cancelFunc()
+
+	errGroup := &errgroup.Group{}
+
+	err := container.Invoke(func(
+		grpcConfig *config.Grpc,
+		passwordConfig *config.Password,
+		repository repository.Repository,
+		tracer trace.Tracer,
+		validator *validator.Validate,
+	) error {
+		if grpcConfig.Address == "" {
+			return nil
+		}
+
+		var options []grpc.ServerOption
+		if certificates != nil {
+			options = append(options, grpc.Creds(credentials.NewTLS(certificates.Config())))
+		}
+
+		server := newGrpcServer(NewServer(passwordConfig, repository, tracer, logger, validator, service, blocker), authenticator, logger, options...)
+
+		listener, err := net.Listen("tcp", grpcConfig.Address)
+		if err != nil {
+			return err
+		}
+
+		errGroup.Go(func() error {
+			defer cancelFunc()
+
+			logger.Infof("grpc server: started on %s", listener.Addr())
+
+			return server.Serve(listener)
+		})
+
+		errGroup.Go(func() error {
+			<-ctx.Done()
+
+			logger.Infof("grpc server: shutdown")
+
+			server.Stop()
+
+			return nil
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return errGroup.Wait()
+}
+
+// newGrpcServer grpc server of the Passwords service with interceptors of propagation, audit and authorization
+func newGrpcServer(passwords PasswordsServer, authenticator auth.Authenticator, logger log.Logger, options ...grpc.ServerOption) *grpc.Server {
+	options = append(options, grpc.ChainUnaryInterceptor(propagate, auditCall, authorize(authenticator, logger)))
+
+	server := grpc.NewServer(options...)
+	RegisterPasswordsServer(server, passwords)
+
+	return server
+}
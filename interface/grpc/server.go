@@ -0,0 +1,400 @@
+package grpc
+
+import (
+	"context"
+	"github.com/Diez37/passwords/application/blocker"
+	service "github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/diez37/go-packages/log"
+	"github.com/diez37/go-packages/router/middlewares"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"net/http"
+)
+
+const (
+	ValidCheckStatus          = "valid"
+	ChangeRequiredCheckStatus = "change_required"
+	ExpiredGraceCheckStatus   = "expired_grace"
+)
+
+// Server implementation of the Passwords service, calls are handled like requests of the http interface
+type Server struct {
+	UnimplementedPasswordsServer
+
+	config     *config.Password
+	repository repository.Repository
+	tracer     trace.Tracer
+	logger     log.Logger
+	validator  *validator.Validate
+
+	service service.Service
+	blocker blocker.Blocker
+}
+
+func NewServer(
+	config *config.Password,
+	repository repository.Repository,
+	tracer trace.Tracer,
+	logger log.Logger,
+	validator *validator.Validate,
+	service service.Service,
+	blocker blocker.Blocker,
+) *Server {
+	return &Server{
+		config:     config,
+		repository: repository,
+		tracer:     tracer,
+		logger:     logger,
+		validator:  validator,
+		service:    service,
+		blocker:    blocker,
+	}
+}
+
+func (server *Server) Add(ctx context.Context, message *Password) (*Result, error) {
+	ctx, span := server.tracer.Start(ctx, "Add")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "grpc"))
+
+	password, err := server.toDomain(message)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	err = server.service.Add(ctx, password)
+	if err != nil && err != service.AlreadyExistError && err != service.LimitExceededError {
+		server.logger.Error(err)
+		return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	if err == service.AlreadyExistError {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+
+	if err == service.LimitExceededError {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return &Result{Status: http.StatusOK, Message: http.StatusText(http.StatusOK)}, nil
+}
+
+func (server *Server) Check(ctx context.Context, message *Password) (*Result, error) {
+	ctx, span := server.tracer.Start(ctx, "Check")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "grpc"))
+
+	password, err := server.toDomain(message)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	checked := &domain.Password{
+		Login:    password.Login,
+		Password: password.Password,
+		Scopes:   password.Scopes,
+	}
+
+	ok, err := server.service.Check(ctx, checked)
+	if err != nil && err != db.RecordNotFoundError {
+		server.logger.Error(err)
+		return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	if err == db.RecordNotFoundError || !ok {
+		return nil, status.Error(codes.PermissionDenied, http.StatusText(http.StatusForbidden))
+	}
+
+	return &Result{Status: http.StatusOK, Message: http.StatusText(http.StatusOK), Check: checkStatus(checked)}, nil
+}
+
+func (server *Server) Change(ctx context.Context, patch *PasswordPatch) (*PasswordForPage, error) {
+	ctx, span := server.tracer.Start(ctx, "Change")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "grpc"))
+
+	uuid, err := uuid.Parse(patch.GetUuid())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	model, err := server.repository.FindByUuid(ctx, uuid)
+	if err != nil && err != db.RecordNotFoundError {
+		server.logger.Error(err)
+		return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	if err == db.RecordNotFoundError {
+		return nil, status.Error(codes.NotFound, http.StatusText(http.StatusNotFound))
+	}
+
+	password := service.ToDomain(model)
+
+	if patch.ValidUntil != nil {
+		password.ValidUntil = toTime(patch.ValidUntil)
+	}
+
+	if patch.OneTime != nil {
+		password.OneTime = *patch.OneTime
+	}
+
+	if patch.Disabled != nil {
+		password.Disabled = *patch.Disabled
+	}
+
+	password, err = server.service.Update(ctx, password)
+	if err != nil && err != repository.ConflictError && err != db.RecordNotFoundError {
+		server.logger.Error(err)
+		return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	if err == repository.ConflictError {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+
+	if err == db.RecordNotFoundError {
+		return nil, status.Error(codes.NotFound, http.StatusText(http.StatusNotFound))
+	}
+
+	return newPasswordForPage(password), nil
+}
+
+func (server *Server) Delete(ctx context.Context, request *DeleteRequest) (*Deletion, error) {
+	ctx, span := server.tracer.Start(ctx, "Delete")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "grpc"))
+
+	uuid, err := uuid.Parse(request.GetUuid())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if !request.GetSync() {
+		server.blocker.Add(ctx, uuid)
+
+		return &Deletion{Uuid: uuid.String(), Status: string(blocker.PendingStatus)}, nil
+	}
+
+	err = server.blocker.Disable(ctx, uuid)
+	if err != nil && err != db.RecordNotFoundError {
+		server.logger.Error(err)
+		return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	if err == db.RecordNotFoundError {
+		return nil, status.Error(codes.NotFound, http.StatusText(http.StatusNotFound))
+	}
+
+	return &Deletion{Uuid: uuid.String(), Status: string(blocker.DisabledStatus)}, nil
+}
+
+func (server *Server) DeleteByLogin(ctx context.Context, request *DeleteByLoginRequest) (*Disabled, error) {
+	ctx, span := server.tracer.Start(ctx, "DeleteByLogin")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "grpc"))
+
+	login, err := uuid.Parse(request.GetLogin())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	var except []uuid.UUID
+	if request.GetExcept() != "" {
+		exceptUuid, err := uuid.Parse(request.GetExcept())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		except = append(except, exceptUuid)
+	}
+
+	count, err := server.blocker.DisableByLogin(ctx, login, except...)
+	if err != nil {
+		server.logger.Error(err)
+		return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	return &Disabled{Count: count}, nil
+}
+
+func (server *Server) List(ctx context.Context, request *ListRequest) (*Page, error) {
+	ctx, span := server.tracer.Start(ctx, "List")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "grpc"))
+
+	login, err := uuid.Parse(request.GetLogin())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	page, limit := middlewares.PageDefault, middlewares.LimitDefault
+	if request.GetPage() > 0 {
+		page = uint(request.GetPage())
+	}
+
+	if request.GetLimit() > 0 {
+		limit = uint(request.GetLimit())
+	}
+
+	var totalCount int64
+	var models []*repository.Password
+
+	wg := &errgroup.Group{}
+
+	wg.Go(func() error {
+		count, err := server.repository.Count(ctx)
+		totalCount = count
+
+		return err
+	})
+
+	wg.Go(func() error {
+		passwords, err := server.repository.Page(ctx, page-1, limit, login)
+		models = passwords
+
+		return err
+	})
+
+	if err := wg.Wait(); err != nil && err != io.EOF {
+		server.logger.Error(err)
+		return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	records := make([]*PasswordForPage, len(models))
+	for index, model := range models {
+		records[index] = newPasswordForPage(service.ToDomain(model))
+	}
+
+	return &Page{
+		Meta:    &Meta{Count: totalCount, Page: uint64(page), Limit: uint64(limit)},
+		Records: records,
+	}, nil
+}
+
+func (server *Server) AddBatch(ctx context.Context, batch *Batch) (*Results, error) {
+	ctx, span := server.tracer.Start(ctx, "AddBatch")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "grpc"))
+
+	passwords, indexes, results, err := server.readBatch(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	errs, err := server.service.AddBatch(ctx, passwords...)
+	if err != nil {
+		server.logger.Error(err)
+		return nil, status.Error(codes.Internal, http.StatusText(http.StatusInternalServerError))
+	}
+
+	for index, err := range errs {
+		result := results[indexes[index]]
+
+		switch err {
+		case nil:
+			result.Status = http.StatusOK
+			result.Uuid = passwords[index].Uuid.String()
+		case service.AlreadyExistError:
+			result.Status = http.StatusConflict
+		case service.LimitExceededError:
+			result.Status = http.StatusUnprocessableEntity
+		default:
+			result.Status = http.StatusInternalServerError
+			server.logger.Error(err)
+		}
+
+		result.Message = http.StatusText(int(result.Status))
+	}
+
+	return &Results{Results: results}, nil
+}
+
+func (server *Server) CheckBatch(ctx context.Context, batch *Batch) (*Results, error) {
+	ctx, span := server.tracer.Start(ctx, "CheckBatch")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "grpc"))
+
+	passwords, indexes, results, err := server.readBatch(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	oks, errs := server.service.CheckBatch(ctx, passwords...)
+
+	for index, err := range errs {
+		result := results[indexes[index]]
+
+		switch {
+		case err != nil && err != db.RecordNotFoundError:
+			result.Status = http.StatusInternalServerError
+			server.logger.Error(err)
+		case err == db.RecordNotFoundError || !oks[index]:
+			result.Status = http.StatusForbidden
+		default:
+			result.Status = http.StatusOK
+			result.Check = checkStatus(passwords[index])
+		}
+
+		result.Message = http.StatusText(int(result.Status))
+	}
+
+	return &Results{Results: results}, nil
+}
+
+// readBatch validation of passwords of the batch, return valid passwords, indexes of them in the batch and
+// results for every password of the batch, invalid passwords get result with http.StatusBadRequest
+func (server *Server) readBatch(batch *Batch) ([]*domain.Password, []int, []*Result, error) {
+	if len(batch.GetPasswords()) > server.config.BatchLimit {
+		return nil, nil, nil, status.Error(codes.ResourceExhausted, http.StatusText(http.StatusRequestEntityTooLarge))
+	}
+
+	var passwords []*domain.Password
+	var indexes []int
+	results := make([]*Result, len(batch.GetPasswords()))
+
+	for index, message := range batch.GetPasswords() {
+		results[index] = &Result{}
+
+		password, err := server.toDomain(message)
+		if err != nil {
+			results[index].Status = http.StatusBadRequest
+			results[index].Message = http.StatusText(http.StatusBadRequest)
+			continue
+		}
+
+		passwords = append(passwords, password)
+		indexes = append(indexes, index)
+	}
+
+	return passwords, indexes, results, nil
+}
+
+// checkStatus outcome of the successful checking of the password
+func checkStatus(password *domain.Password) string {
+	if password.ExpiredGrace {
+		return ExpiredGraceCheckStatus
+	}
+
+	if password.MustChange {
+		return ChangeRequiredCheckStatus
+	}
+
+	return ValidCheckStatus
+}
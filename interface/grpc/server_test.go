@@ -0,0 +1,265 @@
+package grpc
+
+import (
+	"context"
+	"github.com/Diez37/passwords/application/auth"
+	"github.com/Diez37/passwords/application/blocker"
+	service "github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"net/http"
+	"testing"
+)
+
+const (
+	testPassword = "correct horse"
+	testTraceId  = "4bf92f3577b34da6a3ce929d0e0e4736"
+)
+
+// testAuthenticator callers by the api key header, the key is the role of the caller
+type testAuthenticator struct{}
+
+func (testAuthenticator) Authenticate(_ context.Context, request *http.Request) (*domain.Caller, error) {
+	role := request.Header.Get(auth.ApiKeyHeaderName)
+	if role == "" {
+		return nil, auth.UnauthenticatedError
+	}
+
+	return &domain.Caller{Identity: "apikey:" + role, Roles: []domain.Role{domain.Role(role)}}, nil
+}
+
+type testService struct {
+	service.Service
+
+	traceId trace.TraceID
+}
+
+func (fake *testService) Add(ctx context.Context, password *domain.Password) error {
+	fake.traceId = trace.SpanContextFromContext(ctx).TraceID()
+
+	if auth.CallerFromContext(ctx) == nil {
+		return db.RecordNotFoundError
+	}
+
+	return nil
+}
+
+func (fake *testService) Check(_ context.Context, password *domain.Password) (bool, error) {
+	if password.Password != testPassword {
+		return false, nil
+	}
+
+	password.MustChange = true
+
+	return true, nil
+}
+
+func (fake *testService) CheckBatch(ctx context.Context, passwords ...*domain.Password) ([]bool, []error) {
+	oks, errs := make([]bool, len(passwords)), make([]error, len(passwords))
+	for index, password := range passwords {
+		oks[index], errs[index] = fake.Check(ctx, password)
+	}
+
+	return oks, errs
+}
+
+type testBlocker struct {
+	blocker.Blocker
+}
+
+func (testBlocker) Add(context.Context, uuid.UUID) {}
+
+func newTestClient(t *testing.T, service *testService) PasswordsClient {
+	t.Helper()
+
+	logger := logrus.New()
+	server := NewServer(
+		&config.Password{BatchLimit: 2},
+		nil,
+		trace.NewNoopTracerProvider().Tracer(""),
+		logger,
+		validator.New(),
+		service,
+		testBlocker{},
+	)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := newGrpcServer(server, testAuthenticator{}, logger)
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	connection, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { connection.Close() })
+
+	return NewPasswordsClient(connection)
+}
+
+func TestServer(t *testing.T) {
+	client := newTestClient(t, &testService{})
+	login := uuid.NewString()
+
+	tests := []struct {
+		name string
+		role string
+		call func(ctx context.Context) error
+		code codes.Code
+	}{
+		{
+			name: "unauthenticated",
+			call: func(ctx context.Context) error {
+				_, err := client.Check(ctx, &Password{Login: login, Password: testPassword})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "check role checks",
+			role: string(domain.CheckRole),
+			call: func(ctx context.Context) error {
+				result, err := client.Check(ctx, &Password{Login: login, Password: testPassword})
+				if err == nil && result.Check != ChangeRequiredCheckStatus {
+					t.Errorf("check status %s, want %s", result.Check, ChangeRequiredCheckStatus)
+				}
+
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "check role cannot add",
+			role: string(domain.CheckRole),
+			call: func(ctx context.Context) error {
+				_, err := client.Add(ctx, &Password{Login: login, Password: testPassword})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "admin role adds",
+			role: string(domain.AdminRole),
+			call: func(ctx context.Context) error {
+				_, err := client.Add(ctx, &Password{Login: login, Password: testPassword})
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "invalid login",
+			role: string(domain.AdminRole),
+			call: func(ctx context.Context) error {
+				_, err := client.Add(ctx, &Password{Login: "login", Password: testPassword})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "wrong password",
+			role: string(domain.CheckRole),
+			call: func(ctx context.Context) error {
+				_, err := client.Check(ctx, &Password{Login: login, Password: "wrong"})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "asynchronous deletion",
+			role: string(domain.AdminRole),
+			call: func(ctx context.Context) error {
+				deletion, err := client.Delete(ctx, &DeleteRequest{Uuid: uuid.NewString()})
+				if err == nil && deletion.Status != string(blocker.PendingStatus) {
+					t.Errorf("deletion status %s, want %s", deletion.Status, blocker.PendingStatus)
+				}
+
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "batch over the limit",
+			role: string(domain.CheckRole),
+			call: func(ctx context.Context) error {
+				_, err := client.CheckBatch(ctx, &Batch{Passwords: []*Password{{}, {}, {}}})
+				return err
+			},
+			code: codes.ResourceExhausted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.role != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, auth.ApiKeyHeaderName, test.role)
+			}
+
+			if code := status.Code(test.call(ctx)); code != test.code {
+				t.Errorf("code %s, want %s", code, test.code)
+			}
+		})
+	}
+}
+
+func TestServerCheckBatch(t *testing.T) {
+	client := newTestClient(t, &testService{})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), auth.ApiKeyHeaderName, string(domain.CheckRole))
+
+	results, err := client.CheckBatch(ctx, &Batch{Passwords: []*Password{
+		{Login: uuid.NewString(), Password: testPassword},
+		{Login: uuid.NewString(), Password: "wrong"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for index, want := range []int32{http.StatusOK, http.StatusForbidden} {
+		if status := results.Results[index].Status; status != want {
+			t.Errorf("status of password %d is %d, want %d", index, status, want)
+		}
+	}
+}
+
+func TestServerPropagation(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	service := &testService{}
+	client := newTestClient(t, service)
+
+	ctx := metadata.AppendToOutgoingContext(
+		context.Background(),
+		auth.ApiKeyHeaderName, string(domain.AdminRole),
+		"traceparent", "00-"+testTraceId+"-00f067aa0ba902b7-01",
+	)
+
+	if _, err := client.Add(ctx, &Password{Login: uuid.NewString(), Password: testPassword}); err != nil {
+		t.Fatal(err)
+	}
+
+	if traceId := service.traceId.String(); traceId != testTraceId {
+		t.Errorf("trace id %s, want %s", traceId, testTraceId)
+	}
+}
@@ -2,39 +2,111 @@ package api
 
 import (
 	"fmt"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/auth"
 	"github.com/Diez37/passwords/application/blocker"
+	"github.com/Diez37/passwords/application/generator"
+	"github.com/Diez37/passwords/application/otp"
 	service "github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/application/recovery"
+	"github.com/Diez37/passwords/application/webhook"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
 	"github.com/Diez37/passwords/infrastructure/repository"
 	"github.com/Diez37/passwords/interface/http/api/v1"
 	"github.com/diez37/go-packages/log"
 	"github.com/diez37/go-packages/router/middlewares"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
+	"net/http"
 )
 
 func Router(
+	config *config.Password,
 	repository repository.Repository,
 	tracer trace.Tracer,
 	logger log.Logger,
 	validator *validator.Validate,
 	service service.Service,
 	blocker blocker.Blocker,
+	generator generator.Generator,
+	otp otp.Service,
+	recovery recovery.Service,
+	auditor audit.Auditor,
+	dispatcher webhook.Dispatcher,
+	authenticator auth.Authenticator,
 ) chi.Router {
-	apiV1 := v1.NewAPI(repository, tracer, logger, validator, service, blocker)
+	apiV1 := v1.NewAPI(config, repository, tracer, logger, validator, service, blocker, generator, otp, recovery, auditor, dispatcher)
 
 	router := chi.NewRouter()
 
+	router.Use(auditRequest)
+
+	// routes without the role are public
+	check := authorize(authenticator, logger, domain.CheckRole)
+	admin := authorize(authenticator, logger, domain.AdminRole)
+
+	router.Get("/openapi.json", Openapi(logger))
+
 	router.Route("/v1/password", func(r chi.Router) {
-		r.Put("/", apiV1.Add)
-		r.Options("/", apiV1.Check)
+		r.With(admin).Put("/", apiV1.Add)
+		r.With(check).Options("/", apiV1.Check)
+		r.With(admin).Post("/generate", apiV1.Generate)
+
+		if otp != nil {
+			r.With(check).Options("/mfa", apiV1.CheckWithCode)
+		}
+
+		r.With(admin).Put("/batch", apiV1.AddBatch)
+		r.With(check).Options("/batch", apiV1.CheckBatch)
 
 		r.Route(fmt.Sprintf("/{%s}", v1.UuidFieldName), func(r chi.Router) {
+			r.Use(admin)
 			r.Use(middlewares.NewUUID(logger, middlewares.WithName(v1.UuidFieldName), middlewares.WithUri(v1.UuidFieldName)).Middleware)
-			r.Delete("/", apiV1.Delete)
+			r.Get("/", apiV1.Get)
+			r.Patch("/", apiV1.Update)
+			r.With(middlewares.NewBool(
+				logger,
+				middlewares.WithName(v1.SyncFieldName),
+				middlewares.WithQuery(v1.SyncFieldName),
+				middlewares.WithDefault(false),
+			).Middleware).Delete("/", apiV1.Delete)
+			r.Get("/deletion", apiV1.Deletion)
+		})
+
+		router.Route("/v1/passwords/expiring", func(r chi.Router) {
+			r.Use(admin)
+			r.Use(middlewares.NewUint64(
+				logger,
+				middlewares.WithName(middlewares.PageFieldName),
+				middlewares.WithQuery(middlewares.PageFieldName),
+				middlewares.WithHeader(middlewares.PageHeaderName),
+				middlewares.WithDefault(middlewares.PageDefault),
+			).Middleware)
+
+			r.Use(middlewares.NewUint64(
+				logger,
+				middlewares.WithName(middlewares.LimitFieldName),
+				middlewares.WithQuery(middlewares.LimitFieldName),
+				middlewares.WithHeader(middlewares.LimitHeaderName),
+				middlewares.WithDefault(middlewares.LimitDefault),
+			).Middleware)
+
+			r.Use(middlewares.NewUint64(
+				logger,
+				middlewares.WithName(v1.WithinFieldName),
+				middlewares.WithQuery(v1.WithinFieldName),
+				middlewares.WithDefault(v1.WithinDefault),
+			).Middleware)
+
+			r.Get("/", apiV1.Expiring)
 		})
 
 		router.Route(fmt.Sprintf("/v1/passwords/{%s}", v1.LoginFieldName), func(r chi.Router) {
+			r.Use(admin)
 			r.Use(middlewares.NewUUID(logger, middlewares.WithName(v1.LoginFieldName), middlewares.WithUri(v1.LoginFieldName)).Middleware)
 			r.Use(middlewares.NewUint64(
 				logger,
@@ -53,8 +125,126 @@ func Router(
 			).Middleware)
 
 			r.Get("/", apiV1.Page)
+			r.With(middlewares.NewUUID(
+				logger,
+				middlewares.WithName(v1.ExceptFieldName),
+				middlewares.WithQuery(v1.ExceptFieldName),
+				middlewares.WithDefault(uuid.Nil),
+			).Middleware).Delete("/", apiV1.DeleteByLogin)
+		})
+	})
+
+	// otp routes are served only with the configured otp service
+	if otp != nil {
+		router.Route(fmt.Sprintf("/v1/otp/{%s}", v1.LoginFieldName), func(r chi.Router) {
+			r.Use(middlewares.NewUUID(logger, middlewares.WithName(v1.LoginFieldName), middlewares.WithUri(v1.LoginFieldName)).Middleware)
+
+			r.With(admin).Post("/", apiV1.EnrollOtp)
+			r.With(check).Options("/", apiV1.VerifyOtp)
+			r.With(admin).Delete("/", apiV1.DisableOtp)
 		})
+	}
+
+	router.Route(fmt.Sprintf("/v1/recovery/{%s}", v1.LoginFieldName), func(r chi.Router) {
+		r.Use(middlewares.NewUUID(logger, middlewares.WithName(v1.LoginFieldName), middlewares.WithUri(v1.LoginFieldName)).Middleware)
+
+		r.With(admin).Post("/", apiV1.GenerateRecovery)
+		r.With(admin).Get("/", apiV1.RemainingRecovery)
+		r.With(check).Options("/", apiV1.UseRecovery)
+		r.With(admin).Delete("/", apiV1.RevokeRecovery)
+	})
+
+	router.Route(fmt.Sprintf("/v1/reset/{%s}", v1.LoginFieldName), func(r chi.Router) {
+		r.Use(middlewares.NewUUID(logger, middlewares.WithName(v1.LoginFieldName), middlewares.WithUri(v1.LoginFieldName)).Middleware)
+
+		r.With(admin).Post("/", apiV1.IssueReset)
+
+		// the reset token is the credential of the login, redeeming is allowed to checking callers
+		r.With(check).Put("/", apiV1.RedeemReset)
+	})
+
+	router.Route(fmt.Sprintf("/v1/audit/{%s}", v1.LoginFieldName), func(r chi.Router) {
+		r.Use(admin)
+		r.Use(middlewares.NewUUID(logger, middlewares.WithName(v1.LoginFieldName), middlewares.WithUri(v1.LoginFieldName)).Middleware)
+		r.Use(middlewares.NewUint64(
+			logger,
+			middlewares.WithName(middlewares.PageFieldName),
+			middlewares.WithQuery(middlewares.PageFieldName),
+			middlewares.WithHeader(middlewares.PageHeaderName),
+			middlewares.WithDefault(middlewares.PageDefault),
+		).Middleware)
+
+		r.Use(middlewares.NewUint64(
+			logger,
+			middlewares.WithName(middlewares.LimitFieldName),
+			middlewares.WithQuery(middlewares.LimitFieldName),
+			middlewares.WithHeader(middlewares.LimitHeaderName),
+			middlewares.WithDefault(middlewares.LimitDefault),
+		).Middleware)
+
+		r.Get("/", apiV1.Audit)
+	})
+
+	router.Route("/v1/webhooks/dead", func(r chi.Router) {
+		r.Use(admin)
+
+		r.With(
+			middlewares.NewUint64(
+				logger,
+				middlewares.WithName(middlewares.PageFieldName),
+				middlewares.WithQuery(middlewares.PageFieldName),
+				middlewares.WithHeader(middlewares.PageHeaderName),
+				middlewares.WithDefault(middlewares.PageDefault),
+			).Middleware,
+			middlewares.NewUint64(
+				logger,
+				middlewares.WithName(middlewares.LimitFieldName),
+				middlewares.WithQuery(middlewares.LimitFieldName),
+				middlewares.WithHeader(middlewares.LimitHeaderName),
+				middlewares.WithDefault(middlewares.LimitDefault),
+			).Middleware,
+		).Get("/", apiV1.DeadWebhooks)
+
+		r.With(
+			middlewares.NewUUID(logger, middlewares.WithName(v1.UuidFieldName), middlewares.WithUri(v1.UuidFieldName)).Middleware,
+		).Post(fmt.Sprintf("/{%s}", v1.UuidFieldName), apiV1.RequeueWebhook)
 	})
 
 	return router
 }
+
+// authorize authentication of the caller of the request and rejection of callers without the role,
+// the caller is passed to handlers by auth.WithCaller
+func authorize(authenticator auth.Authenticator, logger log.Logger, role domain.Role) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			caller, err := authenticator.Authenticate(request.Context(), request)
+			if err != nil && err != auth.UnauthenticatedError {
+				http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				logger.Error(err)
+				return
+			}
+
+			if err == auth.UnauthenticatedError {
+				http.Error(writer, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			if !caller.Has(role) {
+				http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(writer, request.WithContext(auth.WithCaller(request.Context(), caller)))
+		})
+	}
+}
+
+// auditRequest passing of id and client address of the request to audit entries of services
+func auditRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx := audit.WithRequest(request.Context(), middleware.GetReqID(request.Context()), request.RemoteAddr)
+
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	_ "embed"
+	"github.com/diez37/go-packages/log"
+	"github.com/go-http-utils/headers"
+	"github.com/ldez/mimetype"
+	"net/http"
+)
+
+//go:embed openapi.json
+var openapi []byte
+
+// Openapi handler of the OpenAPI 3 specification of the api
+func Openapi(logger log.Logger) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set(headers.ContentType, mimetype.ApplicationJSON)
+		writer.WriteHeader(http.StatusOK)
+
+		if _, err := writer.Write(openapi); err != nil {
+			logger.Error(err)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/Diez37/passwords/application/otp"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// openapiMethods operations of the path item of the specification, other keys are not operations
+var openapiMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPut:     true,
+	http.MethodPost:    true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+	http.MethodHead:    true,
+	http.MethodPatch:   true,
+	http.MethodTrace:   true,
+}
+
+// testOtp configured otp service, the router only checks that it is set
+type testOtp struct {
+	otp.Service
+}
+
+func newTestRouter() chi.Router {
+	return newTestRouterWith(&testOtp{})
+}
+
+func newTestRouterWith(otp otp.Service) chi.Router {
+	return Router(
+		&config.Password{},
+		nil,
+		trace.NewNoopTracerProvider().Tracer(""),
+		logrus.New(),
+		validator.New(),
+		nil,
+		nil,
+		nil,
+		otp,
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+}
+
+// drifts routes of the router missing in the specification and operations of the specification missing in the router
+func drifts(t *testing.T, router chi.Routes) []string {
+	t.Helper()
+
+	specification := struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}{}
+
+	if err := json.Unmarshal(openapi, &specification); err != nil {
+		t.Fatal(err)
+	}
+
+	operations := map[string]bool{}
+	for path, item := range specification.Paths {
+		for method := range item {
+			if method = strings.ToUpper(method); openapiMethods[method] {
+				operations[fmt.Sprintf("%s %s", method, path)] = true
+			}
+		}
+	}
+
+	routes := map[string]bool{}
+	err := chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+
+		routes[fmt.Sprintf("%s %s", method, route)] = true
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var drifts []string
+	for route := range routes {
+		if !operations[route] {
+			drifts = append(drifts, fmt.Sprintf("%s is not specified", route))
+		}
+	}
+
+	for operation := range operations {
+		if !routes[operation] {
+			drifts = append(drifts, fmt.Sprintf("%s is not routed", operation))
+		}
+	}
+
+	sort.Strings(drifts)
+
+	return drifts
+}
+
+func TestOpenapi(t *testing.T) {
+	tests := []struct {
+		name   string
+		router func() chi.Router
+		drift  string
+	}{
+		{
+			name:   "routes of the api",
+			router: newTestRouter,
+		},
+		{
+			name: "unspecified route",
+			router: func() chi.Router {
+				router := newTestRouter()
+				router.Get("/v1/unspecified", func(http.ResponseWriter, *http.Request) {})
+
+				return router
+			},
+			drift: "GET /v1/unspecified is not specified",
+		},
+		{
+			name: "otp routes without the otp service",
+			router: func() chi.Router {
+				return newTestRouterWith(nil)
+			},
+			drift: "OPTIONS /v1/password/mfa is not routed",
+		},
+		{
+			name: "unrouted operation",
+			router: func() chi.Router {
+				router := chi.NewRouter()
+				router.Get("/openapi.json", Openapi(logrus.New()))
+
+				return router
+			},
+			drift: "PUT /v1/password is not routed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			drifts := drifts(t, test.router())
+
+			if test.drift == "" && len(drifts) > 0 {
+				t.Errorf("routes and the specification drifted apart: %s", strings.Join(drifts, ", "))
+			}
+
+			if index := sort.SearchStrings(drifts, test.drift); test.drift != "" && (index == len(drifts) || drifts[index] != test.drift) {
+				t.Errorf("drifts %v, want %s", drifts, test.drift)
+			}
+		})
+	}
+}
+
+func TestOpenapiHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	specification := struct {
+		Openapi string `json:"openapi"`
+	}{}
+
+	if err := json.Unmarshal(recorder.Body.Bytes(), &specification); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(specification.Openapi, "3.") {
+		t.Errorf("openapi version %s, want 3.x", specification.Openapi)
+	}
+}
@@ -1,11 +1,20 @@
 package v1
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"github.com/Diez37/passwords/application/audit"
 	"github.com/Diez37/passwords/application/blocker"
+	"github.com/Diez37/passwords/application/generator"
+	"github.com/Diez37/passwords/application/otp"
 	service "github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/application/recovery"
+	"github.com/Diez37/passwords/application/webhook"
 	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
 	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
 	"github.com/diez37/go-packages/clients/db"
 	"github.com/diez37/go-packages/log"
 	"github.com/diez37/go-packages/router/middlewares"
@@ -20,27 +29,54 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	goTime "time"
 )
 
 type API struct {
+	config     *config.Password
 	repository repository.Repository
 	tracer     trace.Tracer
 	logger     log.Logger
 	validator  *validator.Validate
 
-	service service.Service
-	blocker blocker.Blocker
+	service    service.Service
+	blocker    blocker.Blocker
+	generator  generator.Generator
+	otp        otp.Service
+	recovery   recovery.Service
+	auditor    audit.Auditor
+	dispatcher webhook.Dispatcher
 }
 
 func NewAPI(
+	config *config.Password,
 	repository repository.Repository,
 	tracer trace.Tracer,
 	logger log.Logger,
 	validator *validator.Validate,
 	service service.Service,
 	blocker blocker.Blocker,
+	generator generator.Generator,
+	otp otp.Service,
+	recovery recovery.Service,
+	auditor audit.Auditor,
+	dispatcher webhook.Dispatcher,
 ) *API {
-	return &API{repository: repository, tracer: tracer, logger: logger, validator: validator, service: service, blocker: blocker}
+	return &API{
+		config:     config,
+		repository: repository,
+		tracer:     tracer,
+		logger:     logger,
+		validator:  validator,
+		service:    service,
+		blocker:    blocker,
+		generator:  generator,
+		otp:        otp,
+		recovery:   recovery,
+		auditor:    auditor,
+		dispatcher: dispatcher,
+	}
 }
 
 func (handler *API) Add(writer http.ResponseWriter, request *http.Request) {
@@ -74,8 +110,14 @@ func (handler *API) Add(writer http.ResponseWriter, request *http.Request) {
 		Password:   password.Password,
 		OneTime:    password.OneTime,
 		ValidUntil: password.ValidUntil,
+		ValidFrom:  password.ValidFrom,
+		MustChange: password.MustChange,
+		Type:       domain.PasswordType(password.Type),
+		Scopes:     password.Scopes,
+		Label:      password.Label,
+		MaxUses:    password.MaxUses,
 	})
-	if err != nil && err != service.AlreadyExistError {
+	if err != nil && err != service.AlreadyExistError && err != service.LimitExceededError {
 		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		handler.logger.Error(err)
 		return
@@ -86,11 +128,16 @@ func (handler *API) Add(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	if err == service.LimitExceededError {
+		http.Error(writer, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+		return
+	}
+
 	writer.WriteHeader(http.StatusOK)
 }
 
-func (handler *API) Check(writer http.ResponseWriter, request *http.Request) {
-	ctx, span := handler.tracer.Start(request.Context(), "Check")
+func (handler *API) Generate(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Generate")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("handler", "api.v1"))
@@ -102,25 +149,127 @@ func (handler *API) Check(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	password := Password{}
-	if err := json.Unmarshal(body, &password); err != nil {
+	generate := Generate{}
+	if err := json.Unmarshal(body, &generate); err != nil {
 		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		handler.logger.Error(err)
 		return
 	}
 
-	if err := handler.validator.Struct(password); err != nil {
+	if err := handler.validator.Struct(generate); err != nil {
 		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 		handler.logger.Error(err)
 		return
 	}
 
-	ok, err := handler.service.Check(ctx, &domain.Password{
+	profile := &generator.Profile{
+		Kind:        generator.Kind(generate.Profile),
+		Length:      generate.Length,
+		Alphabet:    generate.Alphabet,
+		Words:       generate.Words,
+		Groups:      generate.Groups,
+		GroupLength: generate.GroupLength,
+		Separator:   generate.Separator,
+	}
+
+	var password *domain.Password
+
+	// a collision with an existing password of the login is possible for short profiles, generation is repeated
+	for attempt := 0; attempt < generateAttempts; attempt++ {
+		plaintext, err := handler.generator.Generate(ctx, profile)
+		if err != nil && err != generator.InvalidProfileError {
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			handler.logger.Error(err)
+			return
+		}
+
+		if err == generator.InvalidProfileError {
+			http.Error(writer, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			return
+		}
+
+		password = &domain.Password{
+			Login:      generate.Login,
+			Password:   plaintext,
+			OneTime:    generate.OneTime,
+			ValidUntil: generate.ValidUntil,
+			ValidFrom:  generate.ValidFrom,
+			MustChange: generate.MustChange,
+			Type:       domain.PasswordType(generate.Type),
+			Scopes:     generate.Scopes,
+			Label:      generate.Label,
+			MaxUses:    generate.MaxUses,
+		}
+
+		err = handler.service.Add(ctx, password)
+		if err == service.AlreadyExistError {
+			password = nil
+			continue
+		}
+
+		if err == service.LimitExceededError {
+			http.Error(writer, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+			return
+		}
+
+		if err != nil {
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			handler.logger.Error(err)
+			return
+		}
+
+		break
+	}
+
+	if password == nil {
+		http.Error(writer, http.StatusText(http.StatusConflict), http.StatusConflict)
+		return
+	}
+
+	writer.Header().Set(headers.CacheControl, "no-store")
+
+	handler.writeJSON(writer, &Generated{
+		Uuid:       password.Uuid,
 		Login:      password.Login,
 		Password:   password.Password,
 		OneTime:    password.OneTime,
 		ValidUntil: password.ValidUntil,
 	})
+}
+
+func (handler *API) Check(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Check")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	password := Password{}
+	if err := json.Unmarshal(body, &password); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err := handler.validator.Struct(password); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	checked := &domain.Password{
+		Login:    password.Login,
+		Password: password.Password,
+		Scopes:   password.Scopes,
+	}
+
+	ok, err := handler.service.Check(ctx, checked)
 	if err != nil && err != db.RecordNotFoundError {
 		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		handler.logger.Error(err)
@@ -132,7 +281,147 @@ func (handler *API) Check(writer http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	writer.WriteHeader(http.StatusOK)
+	handler.writeJSON(writer, &Checked{Uuid: checked.Uuid, Status: checkStatus(checked)})
+}
+
+func (handler *API) AddBatch(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "AddBatch")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	passwords, indexes, results, ok := handler.readBatch(writer, request)
+	if !ok {
+		return
+	}
+
+	errs, err := handler.service.AddBatch(ctx, passwords...)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	for index, err := range errs {
+		result := results[indexes[index]]
+
+		switch err {
+		case nil:
+			result.Status = http.StatusOK
+			result.Uuid = &passwords[index].Uuid
+		case service.AlreadyExistError:
+			result.Status = http.StatusConflict
+		case service.LimitExceededError:
+			result.Status = http.StatusUnprocessableEntity
+		default:
+			result.Status = http.StatusInternalServerError
+			handler.logger.Error(err)
+		}
+
+		result.Message = http.StatusText(result.Status)
+	}
+
+	handler.writeJSON(writer, results)
+}
+
+func (handler *API) CheckBatch(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "CheckBatch")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	passwords, indexes, results, ok := handler.readBatch(writer, request)
+	if !ok {
+		return
+	}
+
+	oks, errs := handler.service.CheckBatch(ctx, passwords...)
+
+	for index, err := range errs {
+		result := results[indexes[index]]
+
+		switch {
+		case err != nil && err != db.RecordNotFoundError:
+			result.Status = http.StatusInternalServerError
+			handler.logger.Error(err)
+		case err == db.RecordNotFoundError || !oks[index]:
+			result.Status = http.StatusForbidden
+		default:
+			result.Status = http.StatusOK
+			result.Check = checkStatus(passwords[index])
+		}
+
+		result.Message = http.StatusText(result.Status)
+	}
+
+	handler.writeJSON(writer, results)
+}
+
+// checkStatus outcome of the successful checking of the password
+func checkStatus(password *domain.Password) string {
+	if password.ExpiredGrace {
+		return ExpiredGraceCheckStatus
+	}
+
+	if password.MustChange {
+		return ChangeRequiredCheckStatus
+	}
+
+	return ValidCheckStatus
+}
+
+// readBatch reading and validation of passwords from the body of the batch request, return valid passwords,
+// indexes of them in the batch and results for every password of the batch, invalid passwords get result
+// with http.StatusBadRequest and are not returned
+func (handler *API) readBatch(writer http.ResponseWriter, request *http.Request) ([]*domain.Password, []int, []*Result, bool) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return nil, nil, nil, false
+	}
+
+	var batch []*Password
+	if err := json.Unmarshal(body, &batch); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return nil, nil, nil, false
+	}
+
+	if len(batch) > handler.config.BatchLimit {
+		http.Error(writer, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return nil, nil, nil, false
+	}
+
+	var passwords []*domain.Password
+	var indexes []int
+	results := make([]*Result, len(batch))
+
+	for index, password := range batch {
+		results[index] = &Result{}
+
+		if password == nil || handler.validator.Struct(password) != nil {
+			results[index].Status = http.StatusBadRequest
+			results[index].Message = http.StatusText(http.StatusBadRequest)
+			continue
+		}
+
+		passwords = append(passwords, &domain.Password{
+			Login:      password.Login,
+			Password:   password.Password,
+			OneTime:    password.OneTime,
+			ValidUntil: password.ValidUntil,
+			ValidFrom:  password.ValidFrom,
+			MustChange: password.MustChange,
+			Type:       domain.PasswordType(password.Type),
+			Scopes:     password.Scopes,
+			Label:      password.Label,
+			MaxUses:    password.MaxUses,
+		})
+		indexes = append(indexes, index)
+	}
+
+	return passwords, indexes, results, true
 }
 
 func (handler *API) Delete(writer http.ResponseWriter, request *http.Request) {
@@ -141,11 +430,169 @@ func (handler *API) Delete(writer http.ResponseWriter, request *http.Request) {
 
 	span.SetAttributes(attribute.String("handler", "api.v1"))
 
-	handler.blocker.Add(ctx, ctx.Value(UuidFieldName).(uuid.UUID))
+	uuid := ctx.Value(UuidFieldName).(uuid.UUID)
 
+	if ctx.Value(SyncFieldName).(bool) {
+		err := handler.blocker.Disable(ctx, uuid)
+		if err != nil && err != db.RecordNotFoundError {
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			handler.logger.Error(err)
+			return
+		}
+
+		if err == db.RecordNotFoundError {
+			http.Error(writer, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	handler.blocker.Add(ctx, uuid)
+
+	writer.Header().Set(headers.Location, fmt.Sprintf("%s/deletion", strings.TrimSuffix(request.URL.Path, "/")))
 	writer.WriteHeader(http.StatusAccepted)
 }
 
+func (handler *API) DeleteByLogin(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "DeleteByLogin")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	login := ctx.Value(LoginFieldName).(uuid.UUID)
+
+	var except []uuid.UUID
+	if exceptUuid := ctx.Value(ExceptFieldName).(uuid.UUID); exceptUuid != uuid.Nil {
+		except = append(except, exceptUuid)
+	}
+
+	count, err := handler.blocker.DisableByLogin(ctx, login, except...)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	handler.writeJSON(writer, &Disabled{Count: count})
+}
+
+func (handler *API) Deletion(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Deletion")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	uuid := ctx.Value(UuidFieldName).(uuid.UUID)
+
+	status, ok := handler.blocker.Status(ctx, uuid)
+	if !ok {
+		http.Error(writer, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	handler.writeJSON(writer, &Deletion{Uuid: uuid, Status: string(status)})
+}
+
+func (handler *API) Get(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Get")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	model, err := handler.repository.FindByUuid(ctx, ctx.Value(UuidFieldName).(uuid.UUID))
+	if err != nil && err != db.RecordNotFoundError {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err == db.RecordNotFoundError {
+		http.Error(writer, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	handler.writePassword(writer, service.ToDomain(model))
+}
+
+func (handler *API) Update(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Update")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	patch := PasswordPatch{}
+	if err := json.Unmarshal(body, &patch); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err := handler.validator.Struct(patch); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	model, err := handler.repository.FindByUuid(ctx, ctx.Value(UuidFieldName).(uuid.UUID))
+	if err != nil && err != db.RecordNotFoundError {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err == db.RecordNotFoundError {
+		http.Error(writer, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if ifMatch := request.Header.Get(headers.IfMatch); ifMatch != "" && ifMatch != "*" && ifMatch != makeETag(model.CreatedAt, model.UpdateAt) {
+		http.Error(writer, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+		return
+	}
+
+	password := service.ToDomain(model)
+
+	if patch.ValidUntil != nil {
+		password.ValidUntil = patch.ValidUntil
+	}
+
+	if patch.OneTime != nil {
+		password.OneTime = *patch.OneTime
+	}
+
+	if patch.Disabled != nil {
+		password.Disabled = *patch.Disabled
+	}
+
+	password, err = handler.service.Update(ctx, password)
+	if err != nil && err != repository.ConflictError && err != db.RecordNotFoundError {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err == repository.ConflictError {
+		http.Error(writer, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+		return
+	}
+
+	if err == db.RecordNotFoundError {
+		http.Error(writer, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	handler.writePassword(writer, password)
+}
+
 func (handler *API) Page(writer http.ResponseWriter, request *http.Request) {
 	ctx, span := handler.tracer.Start(request.Context(), "Page")
 	defer span.End()
@@ -155,8 +602,7 @@ func (handler *API) Page(writer http.ResponseWriter, request *http.Request) {
 		attribute.String("handler", "api.v1"),
 	)
 
-	page := ctx.Value(middlewares.PageFieldName).(uint)
-	limit := ctx.Value(middlewares.LimitFieldName).(uint)
+	page, limit := pagination(ctx)
 	login := ctx.Value(LoginFieldName).(uuid.UUID)
 
 	var totalCount int64
@@ -186,13 +632,7 @@ func (handler *API) Page(writer http.ResponseWriter, request *http.Request) {
 
 	passwords := make([]*PasswordForPage, len(models))
 	for index, password := range models {
-		passwords[index] = &PasswordForPage{
-			Uuid:       password.Uuid,
-			Login:      password.Login,
-			OneTime:    password.OneTime,
-			ValidUntil: password.ValidUntil,
-			Disabled:   password.Disabled,
-		}
+		passwords[index] = NewPasswordForPage(service.ToDomain(password))
 	}
 
 	content, err := json.Marshal(&Page{
@@ -220,3 +660,114 @@ func (handler *API) Page(writer http.ResponseWriter, request *http.Request) {
 		handler.logger.Error(err)
 	}
 }
+
+func (handler *API) Expiring(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Expiring")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("interface", "http"),
+		attribute.String("handler", "api.v1"),
+	)
+
+	page, limit := pagination(ctx)
+	within := ctx.Value(WithinFieldName).(uint64)
+
+	until := time.NowUTC().Add(goTime.Duration(within) * 24 * goTime.Hour)
+
+	var totalCount int64
+	var models []*repository.Password
+
+	wg := &errgroup.Group{}
+
+	wg.Go(func() error {
+		count, err := handler.repository.CountExpiring(ctx, until)
+		totalCount = count
+
+		return err
+	})
+
+	wg.Go(func() error {
+		passwords, err := handler.repository.Expiring(ctx, until, page-1, limit)
+		models = passwords
+
+		return err
+	})
+
+	if err := wg.Wait(); err != nil && err != db.RecordNotFoundError {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	passwords := make([]*PasswordForPage, len(models))
+	for index, password := range models {
+		passwords[index] = NewPasswordForPage(service.ToDomain(password))
+	}
+
+	writer.Header().Set(middlewares.CountHeaderName, strconv.FormatInt(totalCount, 10))
+	writer.Header().Set(middlewares.PageHeaderName, strconv.FormatUint(uint64(page), 10))
+	writer.Header().Set(middlewares.LimitHeaderName, strconv.FormatUint(uint64(limit), 10))
+
+	handler.writeJSON(writer, &Page{
+		Meta: &Meta{
+			Count: totalCount,
+			Page:  page,
+			Limit: limit,
+		},
+		Records: passwords,
+	})
+}
+
+// pagination page and limit of the request, the defaults are uint, but passed values are cast to uint64
+func pagination(ctx context.Context) (uint, uint) {
+	values := make([]uint, 2)
+
+	for index, name := range []string{middlewares.PageFieldName, middlewares.LimitFieldName} {
+		switch value := ctx.Value(name).(type) {
+		case uint:
+			values[index] = value
+		case uint64:
+			values[index] = uint(value)
+		}
+	}
+
+	return values[0], values[1]
+}
+
+func (handler *API) writePassword(writer http.ResponseWriter, password *domain.Password) {
+	writer.Header().Set(headers.ETag, makeETag(password.CreatedAt, password.UpdateAt))
+
+	handler.writeJSON(writer, NewPasswordForPage(password))
+}
+
+// writeJSON writing of the value as json body with http.StatusOK, other headers must be set before
+func (handler *API) writeJSON(writer http.ResponseWriter, value interface{}) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	writer.Header().Set(headers.ContentType, mimetype.ApplicationJSON)
+	writer.WriteHeader(http.StatusOK)
+
+	if _, err := writer.Write(content); err != nil {
+		handler.logger.Error(err)
+	}
+}
+
+// makeETag version of the password record, the last update time or the creation time for never updated records
+func makeETag(createdAt *goTime.Time, updateAt *goTime.Time) string {
+	version := createdAt
+	if updateAt != nil {
+		version = updateAt
+	}
+
+	if version == nil {
+		return `""`
+	}
+
+	return strconv.Quote(strconv.FormatInt(version.UnixNano(), 10))
+}
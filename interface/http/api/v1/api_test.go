@@ -0,0 +1,160 @@
+package v1
+
+import (
+	"context"
+	service "github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/Diez37/passwords/infrastructure/repository"
+	"github.com/Diez37/passwords/infrastructure/time"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/go-http-utils/headers"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	goTime "time"
+)
+
+// testRepository one stored password
+type testRepository struct {
+	repository.Repository
+
+	password *repository.Password
+}
+
+func (fake *testRepository) FindByUuid(_ context.Context, uuid uuid.UUID) (*repository.Password, error) {
+	if fake.password.Uuid != uuid {
+		return nil, db.RecordNotFoundError
+	}
+
+	return fake.password, nil
+}
+
+// testUpdater updated passwords, the update fails with err if it is set
+type testUpdater struct {
+	service.Service
+
+	updated *domain.Password
+	err     error
+}
+
+func (fake *testUpdater) Update(_ context.Context, password *domain.Password) (*domain.Password, error) {
+	if fake.err != nil {
+		return nil, fake.err
+	}
+
+	fake.updated = password
+
+	return password, nil
+}
+
+func TestUpdate(t *testing.T) {
+	createdAt := time.NowUTC().Add(-goTime.Hour)
+	validUntil := time.NowUTC().Add(goTime.Hour)
+	extended := validUntil.Add(24 * goTime.Hour).Truncate(goTime.Second)
+
+	tests := []struct {
+		name       string
+		body       string
+		unknown    bool
+		ifMatch    string
+		err        error
+		status     int
+		validUntil goTime.Time
+		oneTime    bool
+		disabled   bool
+	}{
+		{
+			name:       "extended validity keeps flags",
+			body:       `{"valid_until":"` + extended.Format(goTime.RFC3339) + `"}`,
+			status:     http.StatusOK,
+			validUntil: extended,
+			oneTime:    true,
+		},
+		{
+			name:       "toggled one-time flag keeps validity",
+			body:       `{"one_time":false}`,
+			ifMatch:    makeETag(&createdAt, nil),
+			status:     http.StatusOK,
+			validUntil: validUntil,
+		},
+		{
+			name:       "disabled password",
+			body:       `{"disabled":true}`,
+			status:     http.StatusOK,
+			validUntil: validUntil,
+			oneTime:    true,
+			disabled:   true,
+		},
+		{name: "invalid body", body: `{"one_time":"yes"}`, status: http.StatusBadRequest},
+		{name: "unknown password", body: `{}`, unknown: true, status: http.StatusNotFound},
+		{name: "outdated version", body: `{}`, ifMatch: `"1"`, status: http.StatusPreconditionFailed},
+		{name: "concurrent update", body: `{}`, err: repository.ConflictError, status: http.StatusPreconditionFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stored := &repository.Password{
+				Uuid:       uuid.New(),
+				Login:      uuid.New(),
+				OneTime:    true,
+				ValidUntil: &validUntil,
+				CreatedAt:  &createdAt,
+			}
+
+			passwords := &testUpdater{err: test.err}
+			handler := NewAPI(
+				&config.Password{},
+				&testRepository{password: stored},
+				trace.NewNoopTracerProvider().Tracer(""),
+				logrus.New(),
+				validator.New(),
+				passwords,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+				nil,
+			)
+
+			target := stored.Uuid
+			if test.unknown {
+				target = uuid.New()
+			}
+
+			request := httptest.NewRequest(http.MethodPatch, "/v1/password/"+target.String(), strings.NewReader(test.body))
+			request = request.WithContext(context.WithValue(request.Context(), UuidFieldName, target))
+
+			if test.ifMatch != "" {
+				request.Header.Set(headers.IfMatch, test.ifMatch)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.Update(recorder, request)
+
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d", recorder.Code, test.status)
+			}
+
+			if test.status != http.StatusOK {
+				return
+			}
+
+			updated := passwords.updated
+
+			if !updated.ValidUntil.Equal(test.validUntil) {
+				t.Errorf("valid until %s, want %s", updated.ValidUntil, test.validUntil)
+			}
+
+			if updated.OneTime != test.oneTime || updated.Disabled != test.disabled {
+				t.Errorf("one-time %t and disabled %t, want %t and %t", updated.OneTime, updated.Disabled, test.oneTime, test.disabled)
+			}
+		})
+	}
+}
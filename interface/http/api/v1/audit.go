@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"github.com/diez37/go-packages/router/middlewares"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func (handler *API) Audit(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Audit")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	page, limit := pagination(ctx)
+	login := ctx.Value(LoginFieldName).(uuid.UUID)
+
+	from, err := parseTime(request.URL.Query().Get(FromFieldName))
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	to, err := parseTime(request.URL.Query().Get(ToFieldName))
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	entries, count, err := handler.auditor.Page(ctx, login, from, to, page-1, limit)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	records := make([]*AuditEntry, len(entries))
+	for index, entry := range entries {
+		records[index] = &AuditEntry{
+			Uuid:      entry.Uuid,
+			Login:     entry.Login,
+			Password:  entry.Password,
+			Action:    string(entry.Action),
+			RequestId: entry.RequestId,
+			Address:   entry.Address,
+			CreatedAt: entry.CreatedAt,
+		}
+	}
+
+	writer.Header().Set(middlewares.CountHeaderName, strconv.FormatInt(count, 10))
+	writer.Header().Set(middlewares.PageHeaderName, strconv.FormatUint(uint64(page), 10))
+	writer.Header().Set(middlewares.LimitHeaderName, strconv.FormatUint(uint64(limit), 10))
+
+	handler.writeJSON(writer, &AuditPage{
+		Meta: &Meta{
+			Count: count,
+			Page:  page,
+			Limit: limit,
+		},
+		Records: records,
+	})
+}
+
+// parseTime parsing of the RFC 3339 time from the query, nil for the empty value
+func parseTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
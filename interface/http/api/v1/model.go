@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"github.com/Diez37/passwords/domain"
 	"github.com/google/uuid"
 	"time"
 )
@@ -10,6 +11,49 @@ type Password struct {
 	Password   string     `json:"password" validate:"required"`
 	OneTime    bool       `json:"one_time" validate:"-"`
 	ValidUntil *time.Time `json:"valid_until" validate:"-"`
+	ValidFrom  *time.Time `json:"valid_from" validate:"-"`
+	MustChange bool       `json:"must_change" validate:"-"`
+	Type       string     `json:"type" validate:"omitempty,oneof=primary app temporary token"`
+
+	// Scopes where the password can be used on adding, scopes required by the caller on checking
+	Scopes []string `json:"scopes" validate:"dive,required,max=64,excludesall=0x2C"`
+	Label  string   `json:"label" validate:"max=255"`
+
+	// MaxUses count of successful checks after which the password is disabled, 0 is unlimited
+	MaxUses int64 `json:"max_uses" validate:"min=0"`
+}
+
+type Generate struct {
+	Login       uuid.UUID  `json:"login" validate:"required"`
+	Profile     string     `json:"profile" validate:"omitempty,oneof=random passphrase pin"`
+	Length      int        `json:"length" validate:"min=0"`
+	Alphabet    string     `json:"alphabet" validate:"-"`
+	Words       int        `json:"words" validate:"min=0"`
+	Groups      int        `json:"groups" validate:"min=0"`
+	GroupLength int        `json:"group_length" validate:"min=0"`
+	Separator   string     `json:"separator" validate:"-"`
+	OneTime     bool       `json:"one_time" validate:"-"`
+	ValidUntil  *time.Time `json:"valid_until" validate:"-"`
+	ValidFrom   *time.Time `json:"valid_from" validate:"-"`
+	MustChange  bool       `json:"must_change" validate:"-"`
+	Type        string     `json:"type" validate:"omitempty,oneof=primary app temporary token"`
+	Scopes      []string   `json:"scopes" validate:"dive,required,max=64,excludesall=0x2C"`
+	Label       string     `json:"label" validate:"max=255"`
+	MaxUses     int64      `json:"max_uses" validate:"min=0"`
+}
+
+type Generated struct {
+	Uuid       uuid.UUID  `json:"uuid"`
+	Login      uuid.UUID  `json:"login"`
+	Password   string     `json:"password"`
+	OneTime    bool       `json:"one_time"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+type PasswordPatch struct {
+	ValidUntil *time.Time `json:"valid_until" validate:"-"`
+	OneTime    *bool      `json:"one_time" validate:"-"`
+	Disabled   *bool      `json:"disabled" validate:"-"`
 }
 
 type Page struct {
@@ -28,5 +72,148 @@ type PasswordForPage struct {
 	Login      uuid.UUID  `json:"login"`
 	OneTime    bool       `json:"one_time"`
 	ValidUntil *time.Time `json:"valid_until"`
+	ValidFrom  *time.Time `json:"valid_from"`
+	MustChange bool       `json:"must_change"`
 	Disabled   bool       `json:"disabled"`
+	Type       string     `json:"type"`
+	Scopes     []string   `json:"scopes"`
+	Label      string     `json:"label"`
+	MaxUses    int64      `json:"max_uses"`
+	UseCount   int64      `json:"use_count"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func NewPasswordForPage(password *domain.Password) *PasswordForPage {
+	return &PasswordForPage{
+		Uuid:       password.Uuid,
+		Login:      password.Login,
+		OneTime:    password.OneTime,
+		ValidUntil: password.ValidUntil,
+		ValidFrom:  password.ValidFrom,
+		MustChange: password.MustChange,
+		Disabled:   password.Disabled,
+		Type:       string(password.Type),
+		Scopes:     password.Scopes,
+		Label:      password.Label,
+		MaxUses:    password.MaxUses,
+		UseCount:   password.UseCount,
+		LastUsedAt: password.LastUsedAt,
+	}
+}
+
+type Deletion struct {
+	Uuid   uuid.UUID `json:"uuid"`
+	Status string    `json:"status"`
+}
+
+type Disabled struct {
+	Count int64 `json:"count"`
+}
+
+type Result struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+
+	// Check outcome of the successful checking, empty for other requests
+	Check string `json:"check,omitempty"`
+
+	// Uuid of the successfully added password, empty for other requests
+	Uuid *uuid.UUID `json:"uuid,omitempty"`
+}
+
+type Checked struct {
+	Uuid   uuid.UUID `json:"uuid"`
+	Status string    `json:"status"`
+}
+
+type OtpEnroll struct {
+	Kind      string `json:"kind" validate:"omitempty,oneof=totp hotp"`
+	Algorithm string `json:"algorithm" validate:"omitempty,oneof=SHA1 SHA256 SHA512"`
+	Digits    int    `json:"digits" validate:"omitempty,min=6,max=8"`
+}
+
+type OtpEnrolled struct {
+	Uuid      uuid.UUID `json:"uuid"`
+	Login     uuid.UUID `json:"login"`
+	Kind      string    `json:"kind"`
+	Algorithm string    `json:"algorithm"`
+	Digits    int       `json:"digits"`
+	Uri       string    `json:"uri"`
+}
+
+type OtpCode struct {
+	Code string `json:"code" validate:"required,numeric"`
+}
+
+type PasswordWithCode struct {
+	Login    uuid.UUID `json:"login" validate:"required"`
+	Password string    `json:"password" validate:"required"`
+	Code     string    `json:"code" validate:"required,numeric"`
+	Scopes   []string  `json:"scopes" validate:"dive,required"`
+}
+
+type RecoveryGenerate struct {
+	Count int `json:"count" validate:"min=0"`
+}
+
+type RecoveryCodes struct {
+	Login uuid.UUID `json:"login"`
+	Codes []string  `json:"codes"`
+}
+
+type RecoveryRemaining struct {
+	Login     uuid.UUID `json:"login"`
+	Remaining int64     `json:"remaining"`
+}
+
+type RecoveryCode struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type ResetIssued struct {
+	Uuid       uuid.UUID  `json:"uuid"`
+	Login      uuid.UUID  `json:"login"`
+	Token      string     `json:"token"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+type Reset struct {
+	Token      string     `json:"token" validate:"required"`
+	Password   string     `json:"password" validate:"required"`
+	ValidUntil *time.Time `json:"valid_until" validate:"-"`
+}
+
+type ResetDone struct {
+	Uuid  uuid.UUID `json:"uuid"`
+	Login uuid.UUID `json:"login"`
+}
+
+type AuditPage struct {
+	Meta    *Meta         `json:"meta"`
+	Records []*AuditEntry `json:"records"`
+}
+
+type AuditEntry struct {
+	Uuid      uuid.UUID  `json:"uuid"`
+	Login     uuid.UUID  `json:"login"`
+	Password  uuid.UUID  `json:"password"`
+	Action    string     `json:"action"`
+	RequestId string     `json:"request_id"`
+	Address   string     `json:"address"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type WebhookPage struct {
+	Meta    *Meta      `json:"meta"`
+	Records []*Webhook `json:"records"`
+}
+
+type Webhook struct {
+	Uuid      uuid.UUID  `json:"uuid"`
+	Event     uuid.UUID  `json:"event"`
+	Url       string     `json:"url"`
+	Payload   string     `json:"payload"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error"`
+	CreatedAt *time.Time `json:"created_at"`
 }
@@ -0,0 +1,192 @@
+package v1
+
+import (
+	"encoding/json"
+	"github.com/Diez37/passwords/application/otp"
+	"github.com/Diez37/passwords/domain"
+	"github.com/diez37/go-packages/clients/db"
+	"github.com/go-http-utils/headers"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"io/ioutil"
+	"net/http"
+)
+
+func (handler *API) EnrollOtp(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "EnrollOtp")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	enroll := OtpEnroll{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &enroll); err != nil {
+			http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			handler.logger.Error(err)
+			return
+		}
+	}
+
+	if err := handler.validator.Struct(enroll); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	model := &domain.Otp{
+		Login:     ctx.Value(LoginFieldName).(uuid.UUID),
+		Kind:      domain.OtpKind(enroll.Kind),
+		Algorithm: enroll.Algorithm,
+		Digits:    enroll.Digits,
+	}
+
+	uri, err := handler.otp.Enroll(ctx, model)
+	if err != nil && err != otp.InvalidKindError {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err == otp.InvalidKindError {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	writer.Header().Set(headers.CacheControl, "no-store")
+
+	handler.writeJSON(writer, &OtpEnrolled{
+		Uuid:      model.Uuid,
+		Login:     model.Login,
+		Kind:      string(model.Kind),
+		Algorithm: model.Algorithm,
+		Digits:    model.Digits,
+		Uri:       uri,
+	})
+}
+
+func (handler *API) VerifyOtp(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "VerifyOtp")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	code := OtpCode{}
+	if err := json.Unmarshal(body, &code); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err := handler.validator.Struct(code); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	ok, err := handler.otp.Verify(ctx, ctx.Value(LoginFieldName).(uuid.UUID), code.Code)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if !ok {
+		http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (handler *API) DisableOtp(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "DisableOtp")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	count, err := handler.otp.Disable(ctx, ctx.Value(LoginFieldName).(uuid.UUID))
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if count == 0 {
+		http.Error(writer, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// CheckWithCode checking of the password and the otp code of the login together, both must be valid
+func (handler *API) CheckWithCode(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "CheckWithCode")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	password := PasswordWithCode{}
+	if err := json.Unmarshal(body, &password); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err := handler.validator.Struct(password); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	checked := &domain.Password{
+		Login:    password.Login,
+		Password: password.Password,
+		Scopes:   password.Scopes,
+	}
+
+	// the code is verified first, checking consumes one-time passwords and uses of limited ones
+	ok, err := handler.otp.Verify(ctx, password.Login, password.Code)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if ok {
+		ok, err = handler.service.Check(ctx, checked)
+		if err != nil && err != db.RecordNotFoundError {
+			http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			handler.logger.Error(err)
+			return
+		}
+	}
+
+	if !ok {
+		http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	handler.writeJSON(writer, &Checked{Uuid: checked.Uuid, Status: checkStatus(checked)})
+}
@@ -0,0 +1,90 @@
+package v1
+
+import (
+	"context"
+	"github.com/Diez37/passwords/application/otp"
+	service "github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/domain"
+	"github.com/Diez37/passwords/infrastructure/config"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testPassword = "correct horse"
+	testCode     = "123456"
+)
+
+// testService passwords of one use, every check consumes the password
+type testService struct {
+	service.Service
+
+	checks int
+}
+
+func (fake *testService) Check(_ context.Context, password *domain.Password) (bool, error) {
+	fake.checks++
+
+	return password.Password == testPassword && fake.checks == 1, nil
+}
+
+type testOtp struct {
+	otp.Service
+}
+
+func (testOtp) Verify(_ context.Context, _ uuid.UUID, code string) (bool, error) {
+	return code == testCode, nil
+}
+
+func TestCheckWithCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		code     string
+		status   int
+		checks   int
+	}{
+		{name: "valid password and code", password: testPassword, code: testCode, status: http.StatusOK, checks: 1},
+		{name: "wrong code keeps the password", password: testPassword, code: "654321", status: http.StatusForbidden},
+		{name: "wrong password", password: "wrong", code: testCode, status: http.StatusForbidden, checks: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			passwords := &testService{}
+			handler := NewAPI(
+				&config.Password{},
+				nil,
+				trace.NewNoopTracerProvider().Tracer(""),
+				logrus.New(),
+				validator.New(),
+				passwords,
+				nil,
+				nil,
+				testOtp{},
+				nil,
+				nil,
+				nil,
+			)
+
+			body := `{"login":"` + uuid.NewString() + `","password":"` + test.password + `","code":"` + test.code + `"}`
+			recorder := httptest.NewRecorder()
+
+			handler.CheckWithCode(recorder, httptest.NewRequest(http.MethodOptions, "/v1/password/mfa", strings.NewReader(body)))
+
+			if recorder.Code != test.status {
+				t.Errorf("status %d, want %d", recorder.Code, test.status)
+			}
+
+			if passwords.checks != test.checks {
+				t.Errorf("%d checks of the password, want %d", passwords.checks, test.checks)
+			}
+		})
+	}
+}
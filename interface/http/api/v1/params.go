@@ -1,6 +1,20 @@
 package v1
 
 const (
-	UuidFieldName  = "uuid"
-	LoginFieldName = "login"
+	generateAttempts = 3
+
+	UuidFieldName   = "uuid"
+	LoginFieldName  = "login"
+	SyncFieldName   = "sync"
+	ExceptFieldName = "except"
+	WithinFieldName = "within"
+	FromFieldName   = "from"
+	ToFieldName     = "to"
+
+	// WithinDefault days of the window of expiring passwords
+	WithinDefault = uint64(30)
+
+	ValidCheckStatus          = "valid"
+	ChangeRequiredCheckStatus = "change_required"
+	ExpiredGraceCheckStatus   = "expired_grace"
 )
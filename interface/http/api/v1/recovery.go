@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"encoding/json"
+	"github.com/Diez37/passwords/application/recovery"
+	"github.com/go-http-utils/headers"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"io/ioutil"
+	"net/http"
+)
+
+func (handler *API) GenerateRecovery(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "GenerateRecovery")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	generate := RecoveryGenerate{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &generate); err != nil {
+			http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			handler.logger.Error(err)
+			return
+		}
+	}
+
+	if err := handler.validator.Struct(generate); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	login := ctx.Value(LoginFieldName).(uuid.UUID)
+
+	codes, err := handler.recovery.Generate(ctx, login, generate.Count)
+	if err != nil && err != recovery.InvalidCountError {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err == recovery.InvalidCountError {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	writer.Header().Set(headers.CacheControl, "no-store")
+
+	handler.writeJSON(writer, &RecoveryCodes{Login: login, Codes: codes})
+}
+
+func (handler *API) RemainingRecovery(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "RemainingRecovery")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	login := ctx.Value(LoginFieldName).(uuid.UUID)
+
+	remaining, err := handler.recovery.Remaining(ctx, login)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	handler.writeJSON(writer, &RecoveryRemaining{Login: login, Remaining: remaining})
+}
+
+func (handler *API) UseRecovery(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "UseRecovery")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	code := RecoveryCode{}
+	if err := json.Unmarshal(body, &code); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err := handler.validator.Struct(code); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	ok, err := handler.recovery.Use(ctx, ctx.Value(LoginFieldName).(uuid.UUID), code.Code)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if !ok {
+		http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	writer.WriteHeader(http.StatusOK)
+}
+
+func (handler *API) RevokeRecovery(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "RevokeRecovery")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	count, err := handler.recovery.Revoke(ctx, ctx.Value(LoginFieldName).(uuid.UUID))
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	handler.writeJSON(writer, &Disabled{Count: count})
+}
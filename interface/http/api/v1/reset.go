@@ -0,0 +1,94 @@
+package v1
+
+import (
+	"encoding/json"
+	service "github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/domain"
+	"github.com/go-http-utils/headers"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"io/ioutil"
+	"net/http"
+)
+
+func (handler *API) IssueReset(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "IssueReset")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	login := ctx.Value(LoginFieldName).(uuid.UUID)
+
+	token, password, err := handler.service.IssueReset(ctx, login)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	writer.Header().Set(headers.CacheControl, "no-store")
+
+	handler.writeJSON(writer, &ResetIssued{
+		Uuid:       password.Uuid,
+		Login:      login,
+		Token:      token,
+		ValidUntil: password.ValidUntil,
+	})
+}
+
+func (handler *API) RedeemReset(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "RedeemReset")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	reset := Reset{}
+	if err := json.Unmarshal(body, &reset); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err := handler.validator.Struct(reset); err != nil {
+		http.Error(writer, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		handler.logger.Error(err)
+		return
+	}
+
+	password := &domain.Password{
+		Login:      ctx.Value(LoginFieldName).(uuid.UUID),
+		Password:   reset.Password,
+		ValidUntil: reset.ValidUntil,
+	}
+
+	ok, err := handler.service.Reset(ctx, reset.Token, password)
+	if err != nil && err != service.AlreadyExistError && err != service.LimitExceededError {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if err == service.AlreadyExistError {
+		http.Error(writer, http.StatusText(http.StatusConflict), http.StatusConflict)
+		return
+	}
+
+	if err == service.LimitExceededError {
+		http.Error(writer, http.StatusText(http.StatusUnprocessableEntity), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if !ok {
+		http.Error(writer, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	handler.writeJSON(writer, &ResetDone{Uuid: password.Uuid, Login: password.Login})
+}
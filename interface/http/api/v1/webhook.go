@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"github.com/diez37/go-packages/router/middlewares"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"strconv"
+)
+
+func (handler *API) DeadWebhooks(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "DeadWebhooks")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	page, limit := pagination(ctx)
+
+	webhooks, count, err := handler.dispatcher.Dead(ctx, page-1, limit)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	records := make([]*Webhook, len(webhooks))
+	for index, webhook := range webhooks {
+		records[index] = &Webhook{
+			Uuid:      webhook.Uuid,
+			Event:     webhook.Event,
+			Url:       webhook.Url,
+			Payload:   webhook.Payload,
+			Attempts:  webhook.Attempts,
+			LastError: webhook.LastError,
+			CreatedAt: webhook.CreatedAt,
+		}
+	}
+
+	writer.Header().Set(middlewares.CountHeaderName, strconv.FormatInt(count, 10))
+	writer.Header().Set(middlewares.PageHeaderName, strconv.FormatUint(uint64(page), 10))
+	writer.Header().Set(middlewares.LimitHeaderName, strconv.FormatUint(uint64(limit), 10))
+
+	handler.writeJSON(writer, &WebhookPage{
+		Meta: &Meta{
+			Count: count,
+			Page:  page,
+			Limit: limit,
+		},
+		Records: records,
+	})
+}
+
+func (handler *API) RequeueWebhook(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "RequeueWebhook")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("handler", "api.v1"))
+
+	requeued, err := handler.dispatcher.Requeue(ctx, ctx.Value(UuidFieldName).(uuid.UUID))
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	if !requeued {
+		http.Error(writer, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	writer.WriteHeader(http.StatusNoContent)
+}
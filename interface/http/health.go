@@ -0,0 +1,74 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/Diez37/passwords/application/health"
+	"github.com/Diez37/passwords/domain"
+	"github.com/diez37/go-packages/log"
+	"github.com/go-http-utils/headers"
+	"github.com/ldez/mimetype"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+)
+
+// HealthReport response of the health probes, the code is 200 when Status is up and 503 otherwise
+type HealthReport struct {
+	Status string         `json:"status"`
+	Checks []*HealthCheck `json:"checks"`
+}
+
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type healthHandler struct {
+	health health.Health
+	logger log.Logger
+	tracer trace.Tracer
+}
+
+func (handler *healthHandler) Live(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Live")
+	defer span.End()
+
+	handler.write(writer, handler.health.Live(ctx))
+}
+
+func (handler *healthHandler) Ready(writer http.ResponseWriter, request *http.Request) {
+	ctx, span := handler.tracer.Start(request.Context(), "Ready")
+	defer span.End()
+
+	handler.write(writer, handler.health.Ready(ctx))
+}
+
+func (handler *healthHandler) write(writer http.ResponseWriter, report *domain.HealthReport) {
+	response := &HealthReport{Status: string(report.Status), Checks: []*HealthCheck{}}
+	for _, check := range report.Checks {
+		response.Checks = append(response.Checks, &HealthCheck{
+			Name:    check.Name,
+			Status:  string(check.Status),
+			Message: check.Message,
+		})
+	}
+
+	content, err := json.Marshal(response)
+	if err != nil {
+		http.Error(writer, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		handler.logger.Error(err)
+		return
+	}
+
+	code := http.StatusOK
+	if report.Status != domain.UpHealthStatus {
+		code = http.StatusServiceUnavailable
+	}
+
+	writer.Header().Set(headers.ContentType, mimetype.ApplicationJSON)
+	writer.WriteHeader(code)
+
+	if _, err := writer.Write(content); err != nil {
+		handler.logger.Error(err)
+	}
+}
@@ -2,8 +2,17 @@ package http
 
 import (
 	"context"
+	"github.com/Diez37/passwords/application/audit"
+	"github.com/Diez37/passwords/application/auth"
 	"github.com/Diez37/passwords/application/blocker"
+	"github.com/Diez37/passwords/application/generator"
+	"github.com/Diez37/passwords/application/health"
+	"github.com/Diez37/passwords/application/otp"
 	"github.com/Diez37/passwords/application/password"
+	"github.com/Diez37/passwords/application/recovery"
+	"github.com/Diez37/passwords/application/webhook"
+	"github.com/Diez37/passwords/infrastructure/certificates"
+	"github.com/Diez37/passwords/infrastructure/config"
 	"github.com/Diez37/passwords/infrastructure/repository"
 	"github.com/Diez37/passwords/interface/http/api"
 	"github.com/diez37/go-packages/container"
@@ -17,13 +26,21 @@ import (
 	"net/http"
 )
 
-// Serve configuration and running http server
+// Serve configuration and running http server, tls is terminated with certificates unless they are nil
 func Serve(
 	ctx context.Context,
 	container container.Container,
 	logger log.Logger,
 	service password.Service,
 	blocker blocker.Blocker,
+	generator generator.Generator,
+	otp otp.Service,
+	recovery recovery.Service,
+	auditor audit.Auditor,
+	dispatcher webhook.Dispatcher,
+	authenticator auth.Authenticator,
+	health health.Health,
+	certificates *certificates.Certificates,
 ) error {
 	ctx, cancelFunc := context.WithCancel(ctx)
 	defer cancelFunc()
@@ -34,30 +51,57 @@ func Serve(
 		server *http.Server,
 		config *httpServer.Config,
 		router chi.Router,
+		passwordConfig *config.Password,
 
 		repository repository.Repository,
 		tracer trace.Tracer,
 		validator *validator.Validate,
 	) {
+		healthHandler := &healthHandler{health: health, logger: logger, tracer: tracer}
+
+		router.Get("/healthz", healthHandler.Live)
+		router.Get("/readyz", healthHandler.Ready)
+
 		router.Mount("/api", api.Router(
+			passwordConfig,
 			repository,
 			tracer,
 			logger,
 			validator,
 			service,
 			blocker,
+			generator,
+			otp,
+			recovery,
+			auditor,
+			dispatcher,
+			authenticator,
 		))
 
+		if certificates != nil {
+			server.TLSConfig = certificates.Config()
+		}
+
 		errGroup.Go(func() error {
 			defer cancelFunc()
 
-			logger.Infof("http server: started")
-
 			server.BaseContext = func(_ net.Listener) context.Context {
 				return ctx
 			}
 
-			if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			var err error
+			if server.TLSConfig != nil {
+				logger.Infof("http server: started with tls")
+
+				// certificates are taken from server.TLSConfig
+				err = server.ListenAndServeTLS("", "")
+			} else {
+				logger.Infof("http server: started")
+
+				err = server.ListenAndServe()
+			}
+
+			if err != http.ErrServerClosed {
 				return err
 			}
 
@@ -2,29 +2,140 @@ package repeater
 
 import (
 	"context"
+	"github.com/Diez37/passwords/application/audit"
 	"github.com/Diez37/passwords/application/blocker"
+	"github.com/Diez37/passwords/application/expiry"
+	"github.com/Diez37/passwords/application/health"
+	"github.com/Diez37/passwords/application/outbox"
+	"github.com/Diez37/passwords/application/webhook"
 	"github.com/Diez37/passwords/infrastructure/config"
+	infrastructureMetrics "github.com/Diez37/passwords/infrastructure/metrics"
 	"github.com/diez37/go-packages/log"
 	"time"
 )
 
-func Serve(ctx context.Context, blockerConfig *config.Blocker, logger log.Logger, blocker blocker.Blocker) {
+func Serve(
+	ctx context.Context,
+	blockerConfig *config.Blocker,
+	expiryConfig *config.Expiry,
+	auditConfig *config.Audit,
+	webhookConfig *config.Webhook,
+	outboxConfig *config.Outbox,
+	logger log.Logger,
+	metrics *infrastructureMetrics.Metrics,
+	blocker blocker.Blocker,
+	expiry expiry.Expiry,
+	auditor audit.Auditor,
+	dispatcher webhook.Dispatcher,
+	outbox outbox.Outbox,
+	health health.Health,
+) {
 	ctx, cancelFunc := context.WithCancel(ctx)
 	defer cancelFunc()
 
 	logger.Info("repeater: started")
 
+	blockTicker := time.NewTicker(blockerConfig.BlockInterval)
+	defer blockTicker.Stop()
+
+	expiryTicker := time.NewTicker(expiryConfig.Interval)
+	defer expiryTicker.Stop()
+
+	checkpointTicker := time.NewTicker(auditConfig.CheckpointInterval)
+	defer checkpointTicker.Stop()
+
+	webhookTicker := time.NewTicker(webhookConfig.Interval)
+	defer webhookTicker.Stop()
+
+	outboxTicker := time.NewTicker(outboxConfig.Interval)
+	defer outboxTicker.Stop()
+
+	health.Expect(infrastructureMetrics.BlockTask, blockerConfig.BlockInterval)
+	health.Expect(infrastructureMetrics.CheckpointTask, auditConfig.CheckpointInterval)
+	health.Expect(infrastructureMetrics.OutboxTask, outboxConfig.Interval)
+
+	if len(expiryConfig.Thresholds) > 0 {
+		health.Expect(infrastructureMetrics.ExpiryTask, expiryConfig.Interval)
+	}
+
+	if len(webhookConfig.Urls) > 0 {
+		health.Expect(infrastructureMetrics.WebhookTask, webhookConfig.Interval)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			logger.Info("repeater: shutdown")
 			return
-		case <-time.After(blockerConfig.BlockInterval):
+		case <-blockTicker.C:
 			logger.Info("repeater: passwords blocking")
 
-			if err := blocker.Block(ctx); err != nil {
+			timer := metrics.Repeater(infrastructureMetrics.BlockTask)
+			err := blocker.Block(ctx)
+			timer.ObserveDuration()
+
+			if err != nil {
+				logger.Error(err)
+				continue
+			}
+
+			health.Beat(infrastructureMetrics.BlockTask)
+		case <-expiryTicker.C:
+			if len(expiryConfig.Thresholds) == 0 {
+				continue
+			}
+
+			logger.Info("repeater: expiry notification")
+
+			timer := metrics.Repeater(infrastructureMetrics.ExpiryTask)
+			err := expiry.Notify(ctx)
+			timer.ObserveDuration()
+
+			if err != nil {
 				logger.Error(err)
+				continue
 			}
+
+			health.Beat(infrastructureMetrics.ExpiryTask)
+		case <-checkpointTicker.C:
+			logger.Info("repeater: audit checkpoint")
+
+			timer := metrics.Repeater(infrastructureMetrics.CheckpointTask)
+			err := auditor.Checkpoint(ctx)
+			timer.ObserveDuration()
+
+			if err != nil {
+				logger.Error(err)
+				continue
+			}
+
+			health.Beat(infrastructureMetrics.CheckpointTask)
+		case <-outboxTicker.C:
+			timer := metrics.Repeater(infrastructureMetrics.OutboxTask)
+			err := outbox.Relay(ctx)
+			timer.ObserveDuration()
+
+			if err != nil {
+				logger.Error(err)
+				continue
+			}
+
+			health.Beat(infrastructureMetrics.OutboxTask)
+		case <-webhookTicker.C:
+			if len(webhookConfig.Urls) == 0 {
+				continue
+			}
+
+			timer := metrics.Repeater(infrastructureMetrics.WebhookTask)
+			err := dispatcher.Deliver(ctx)
+			timer.ObserveDuration()
+
+			if err != nil {
+				logger.Error(err)
+				continue
+			}
+
+			health.Beat(infrastructureMetrics.WebhookTask)
 		}
 	}
 }
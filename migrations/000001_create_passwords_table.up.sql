@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS passwords
+(
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid        VARCHAR(36)  NOT NULL UNIQUE,
+    login       VARCHAR(36)  NOT NULL,
+    password    VARCHAR(255) NOT NULL,
+    disabled    BOOLEAN      NOT NULL DEFAULT FALSE,
+    one_time    BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at  DATETIME     NOT NULL,
+    update_at   DATETIME     NULL,
+    valid_until DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS passwords_login_disabled_index ON passwords (login, disabled);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS otps
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid       VARCHAR(36) NOT NULL UNIQUE,
+    login      VARCHAR(36) NOT NULL,
+    kind       VARCHAR(8)  NOT NULL,
+    secret     TEXT        NOT NULL,
+    algorithm  VARCHAR(8)  NOT NULL,
+    digits     INTEGER     NOT NULL,
+    period     INTEGER     NOT NULL,
+    counter    INTEGER     NOT NULL DEFAULT 0,
+    disabled   BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at DATETIME    NOT NULL,
+    update_at  DATETIME    NULL
+);
+
+CREATE INDEX IF NOT EXISTS otps_login_disabled_index ON otps (login, disabled);
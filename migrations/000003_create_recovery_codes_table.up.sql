@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS recovery_codes
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid       VARCHAR(36) NOT NULL UNIQUE,
+    login      VARCHAR(36) NOT NULL,
+    batch      VARCHAR(36) NOT NULL,
+    hash       VARCHAR(64) NOT NULL,
+    used       BOOLEAN     NOT NULL DEFAULT FALSE,
+    disabled   BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at DATETIME    NOT NULL,
+    used_at    DATETIME    NULL
+);
+
+CREATE INDEX IF NOT EXISTS recovery_codes_login_hash_index ON recovery_codes (login, hash);
@@ -0,0 +1,3 @@
+ALTER TABLE passwords DROP COLUMN label;
+ALTER TABLE passwords DROP COLUMN scopes;
+ALTER TABLE passwords DROP COLUMN type;
@@ -0,0 +1,3 @@
+ALTER TABLE passwords ADD COLUMN type VARCHAR(16) NOT NULL DEFAULT 'primary';
+ALTER TABLE passwords ADD COLUMN scopes TEXT NOT NULL DEFAULT '';
+ALTER TABLE passwords ADD COLUMN label VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+ALTER TABLE passwords DROP COLUMN last_used_at;
+ALTER TABLE passwords DROP COLUMN use_count;
+ALTER TABLE passwords DROP COLUMN max_uses;
@@ -0,0 +1,3 @@
+ALTER TABLE passwords ADD COLUMN max_uses INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE passwords ADD COLUMN use_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE passwords ADD COLUMN last_used_at DATETIME NULL;
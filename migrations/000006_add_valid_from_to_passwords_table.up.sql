@@ -0,0 +1 @@
+ALTER TABLE passwords ADD COLUMN valid_from DATETIME NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS passwords_disabled_valid_until_index;
+
+ALTER TABLE passwords DROP COLUMN expiry_notice;
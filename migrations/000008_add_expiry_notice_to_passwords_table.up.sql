@@ -0,0 +1,3 @@
+ALTER TABLE passwords ADD COLUMN expiry_notice INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS passwords_disabled_valid_until_index ON passwords (disabled, valid_until);
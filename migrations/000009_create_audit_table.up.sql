@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS audit
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid       VARCHAR(36)  NOT NULL UNIQUE,
+    login      VARCHAR(36)  NOT NULL,
+    password   VARCHAR(36)  NOT NULL,
+    action     VARCHAR(32)  NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    address    VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME     NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_login_created_at_index ON audit (login, created_at);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS audit_checkpoints;
+
+DROP INDEX IF EXISTS audit_previous_hash_index;
+
+ALTER TABLE audit DROP COLUMN hash;
+ALTER TABLE audit DROP COLUMN previous_hash;
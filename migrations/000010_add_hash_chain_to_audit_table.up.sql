@@ -0,0 +1,14 @@
+ALTER TABLE audit ADD COLUMN previous_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE audit ADD COLUMN hash VARCHAR(64) NOT NULL DEFAULT '';
+
+-- entries written before chaining have empty hashes, a fork of the chain is rejected for chained entries only
+CREATE UNIQUE INDEX IF NOT EXISTS audit_previous_hash_index ON audit (previous_hash) WHERE hash != '';
+
+CREATE TABLE IF NOT EXISTS audit_checkpoints
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    audit_id   INTEGER     NOT NULL,
+    hash       VARCHAR(64) NOT NULL,
+    signature  VARCHAR(88) NOT NULL,
+    created_at DATETIME    NOT NULL
+);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid            VARCHAR(36)   NOT NULL UNIQUE,
+    event           VARCHAR(36)   NOT NULL,
+    url             VARCHAR(2048) NOT NULL,
+    payload         TEXT          NOT NULL,
+    attempts        INTEGER       NOT NULL DEFAULT 0,
+    last_error      TEXT          NOT NULL DEFAULT '',
+    dead            BOOLEAN       NOT NULL DEFAULT FALSE,
+    next_attempt_at DATETIME      NOT NULL,
+    delivered_at    DATETIME      NULL,
+    created_at      DATETIME      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_dead_next_attempt_at_index ON webhooks (dead, next_attempt_at) WHERE delivered_at IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS webhooks_event_url_index;
+
+DROP TABLE IF EXISTS outbox_cursors;
+
+DROP TABLE IF EXISTS outbox;
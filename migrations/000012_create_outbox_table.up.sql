@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS outbox
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    uuid       VARCHAR(36)  NOT NULL UNIQUE,
+    action     VARCHAR(32)  NOT NULL,
+    login      VARCHAR(36)  NOT NULL,
+    password   VARCHAR(36)  NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    payload    TEXT         NOT NULL,
+    created_at DATETIME     NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS outbox_cursors
+(
+    sink      VARCHAR(32) PRIMARY KEY,
+    outbox_id INTEGER     NOT NULL DEFAULT 0,
+    update_at DATETIME    NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS webhooks_event_url_index ON webhooks (event, url);